@@ -0,0 +1,137 @@
+// Command configor-crypt manages the encrypted values configor decrypts
+// while loading, see configor.Encrypt. Files are rewritten in place, only
+// the values it touches change.
+//
+//	configor-crypt keygen > config.key
+//	configor-crypt encrypt -key-file config.key -path db.password config.yaml
+//	echo -n s3cret | configor-crypt encrypt -key-file config.key
+//	configor-crypt rotate -key-file old.key -new-key-file new.key config.yaml
+//
+// Keys are read from -key-file or else from the env var named by -key-env,
+// CONFIGOR_KEY by default.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cocktail828/go-kits/configor"
+)
+
+type paths []string
+
+func (p *paths) String() string     { return strings.Join(*p, ",") }
+func (p *paths) Set(s string) error { *p = append(*p, s); return nil }
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s keygen|encrypt|rotate [flags] [file]\n", os.Args[0])
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(flag.Arg(0), flag.Args()[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(cmd string, args []string) error {
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	keyFile := fs.String("key-file", "", "file holding the key")
+	keyEnv := fs.String("key-env", "CONFIGOR_KEY", "env var holding the key if -key-file is not given")
+
+	switch cmd {
+	case "keygen":
+		fs.Parse(args)
+		key, err := configor.GenerateKey()
+		if err != nil {
+			return err
+		}
+		fmt.Println(key)
+		return nil
+
+	case "encrypt":
+		var p paths
+		fs.Var(&p, "path", "dotted key path of a value to encrypt, may be repeated")
+		fs.Parse(args)
+		key, err := readKey(*keyFile, *keyEnv)
+		if err != nil {
+			return err
+		}
+		if fs.NArg() == 0 {
+			// encrypt a single value, e.g. for an env var
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return err
+			}
+			value, err := configor.Encrypt(key, string(data))
+			if err != nil {
+				return err
+			}
+			fmt.Println(value)
+			return nil
+		}
+		if len(p) == 0 {
+			return fmt.Errorf("-path is required to encrypt values of a file")
+		}
+		return rewrite(fs.Args(), func(fname string, data []byte) ([]byte, error) {
+			return configor.EncryptValues(data, filepath.Ext(fname), key, p...)
+		})
+
+	case "rotate":
+		newKeyFile := fs.String("new-key-file", "", "file holding the new key")
+		newKeyEnv := fs.String("new-key-env", "CONFIGOR_NEW_KEY", "env var holding the new key if -new-key-file is not given")
+		fs.Parse(args)
+		oldKey, err := readKey(*keyFile, *keyEnv)
+		if err != nil {
+			return err
+		}
+		newKey, err := readKey(*newKeyFile, *newKeyEnv)
+		if err != nil {
+			return err
+		}
+		return rewrite(fs.Args(), func(fname string, data []byte) ([]byte, error) {
+			return configor.RotateEncrypted(data, oldKey, newKey)
+		})
+
+	default:
+		return fmt.Errorf("unknown command %q", cmd)
+	}
+}
+
+func readKey(fname, env string) ([]byte, error) {
+	if fname != "" {
+		return configor.KeyFromFile(fname).Key()
+	}
+	return configor.KeyFromEnv(env).Key()
+}
+
+// rewrite applies f to every file and writes the result back in place
+func rewrite(files []string, f func(fname string, data []byte) ([]byte, error)) error {
+	for _, fname := range files {
+		info, err := os.Stat(fname)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(fname)
+		if err != nil {
+			return err
+		}
+		out, err := f(fname, data)
+		if err != nil {
+			return fmt.Errorf("%s: %v", fname, err)
+		}
+		if err := os.WriteFile(fname, out, info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
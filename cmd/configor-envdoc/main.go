@@ -0,0 +1,59 @@
+// Command configor-envdoc lists the environment variables a configuration
+// struct reads. It takes the JSON Schema written by configor.JSONSchema,
+// so the struct only needs to be described once, e.g. from a test:
+//
+//	data, _ := configor.JSONSchema(&Config{})
+//	os.WriteFile("config.schema.json", data, 0644)
+//
+// and then
+//
+//	configor-envdoc -format markdown config.schema.json > ENV.md
+//	configor-envdoc -format dotenv < config.schema.json > .env.example
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/cocktail828/go-kits/configor"
+)
+
+func main() {
+	format := flag.String("format", "markdown", "output format: markdown, dotenv or json")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [-format markdown|dotenv|json] [schema.json]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if err := run(*format, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(format string, args []string) error {
+	var (
+		data []byte
+		err  error
+	)
+	switch len(args) {
+	case 0:
+		data, err = io.ReadAll(os.Stdin)
+	case 1:
+		data, err = os.ReadFile(args[0])
+	default:
+		return fmt.Errorf("expect at most one schema file, got %d", len(args))
+	}
+	if err != nil {
+		return err
+	}
+
+	vars, err := configor.EnvVarsFromSchema(data)
+	if err != nil {
+		return err
+	}
+	return configor.WriteEnvVars(os.Stdout, vars, format)
+}
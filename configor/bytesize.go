@@ -0,0 +1,108 @@
+package configor
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ByteSize is a number of bytes written the human way, like `512`,
+// `10MB`, `1.5GiB` or `64k`. Decimal units (k, M, G, T, P) count in
+// powers of 1000, binary units (Ki, Mi, Gi, Ti, Pi) in powers of 1024, the
+// trailing `B` and the case of the unit are optional.
+type ByteSize uint64
+
+const (
+	KB ByteSize = 1000
+	MB          = KB * 1000
+	GB          = MB * 1000
+	TB          = GB * 1000
+	PB          = TB * 1000
+
+	KiB ByteSize = 1 << 10
+	MiB          = KiB << 10
+	GiB          = MiB << 10
+	TiB          = GiB << 10
+	PiB          = TiB << 10
+)
+
+var byteUnits = []struct {
+	name string
+	size ByteSize
+}{
+	{"PiB", PiB}, {"TiB", TiB}, {"GiB", GiB}, {"MiB", MiB}, {"KiB", KiB},
+	{"PB", PB}, {"TB", TB}, {"GB", GB}, {"MB", MB}, {"KB", KB},
+}
+
+// ParseByteSize parses a human byte size, see ByteSize
+func ParseByteSize(s string) (ByteSize, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i < 0 {
+		i = len(s)
+	}
+	num, unit := s[:i], strings.ToLower(strings.TrimSpace(s[i:]))
+	if num == "" {
+		return 0, errors.Errorf("invalid byte size %q", s)
+	}
+
+	unit = strings.TrimSuffix(unit, "b")
+	size := ByteSize(1)
+	switch unit {
+	case "":
+	case "k", "ki":
+		size = KB
+	case "m", "mi":
+		size = MB
+	case "g", "gi":
+		size = GB
+	case "t", "ti":
+		size = TB
+	case "p", "pi":
+		size = PB
+	default:
+		return 0, errors.Errorf("invalid byte size %q: unknown unit", s)
+	}
+	if strings.HasSuffix(unit, "i") {
+		size = map[ByteSize]ByteSize{KB: KiB, MB: MiB, GB: GiB, TB: TiB, PB: PiB}[size]
+	}
+
+	if n, err := strconv.ParseUint(num, 10, 64); err == nil {
+		if n > uint64(^ByteSize(0)/size) {
+			return 0, errors.Errorf("invalid byte size %q: out of range", s)
+		}
+		return ByteSize(n) * size, nil
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil || f*float64(size) >= float64(^ByteSize(0)) {
+		return 0, errors.Errorf("invalid byte size %q", s)
+	}
+	return ByteSize(f * float64(size)), nil
+}
+
+// String writes b in the largest unit that divides it, e.g. `10MiB`
+func (b ByteSize) String() string {
+	if b == 0 {
+		return "0B"
+	}
+	for _, u := range byteUnits {
+		if b%u.size == 0 {
+			return strconv.FormatUint(uint64(b/u.size), 10) + u.name
+		}
+	}
+	return strconv.FormatUint(uint64(b), 10) + "B"
+}
+
+func (b ByteSize) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	size, err := ParseByteSize(string(text))
+	if err != nil {
+		return err
+	}
+	*b = size
+	return nil
+}
@@ -1,40 +1,200 @@
 package configor
 
 import (
+	"io"
+	"io/fs"
 	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/BurntSushi/toml"
+	"github.com/cocktail828/go-kits/pkg/logger"
+	"github.com/go-playground/validator/v10"
+	"github.com/pkg/errors"
 )
 
 type Configor struct {
 	EnvPrefix   string
 	Unmarshaler func([]byte, any) error
+
+	// Environment selects the profile overlay of every file, `config.yaml`
+	// is followed by `config.<Environment>.yaml`. New sets it from CONFIGOR_ENV.
+	Environment string
+
+	// WatchInterval is how often LoadAndWatch checks the files, default 1s
+	WatchInterval time.Duration
+
+	// FlagSet overrides fields with command line flags if it is set
+	FlagSet FlagSet
+
+	// Overrides maps dotted field paths like `DB.Port` or `Contacts.0.Email`
+	// to values, they apply after every other layer, see Set
+	Overrides map[string]string
+
+	// Interpolate expands `${VAR}`, `${VAR:-default}` and `${file:/path}`
+	// in the string values of payloads and in include paths. An undefined
+	// variable fails the load, `${VAR:-}` expands it to "" instead. See
+	// interpolateTree for how expanded values decode.
+	Interpolate bool
+
+	// Strict fails a load when a source has keys no field decodes, see
+	// UnknownFieldError
+	Strict bool
+
+	// UnusedEnv is what to do about env vars under EnvPrefix that no field
+	// reads, warnings go to Logger or the default slog logger if it is nil
+	UnusedEnv UnusedEnv
+	Logger    logger.Logger
+
+	// KeyProvider supplies the key of encrypted values, see Encrypt. New
+	// reads it from the file named by CONFIGOR_KEY_FILE or from CONFIGOR_KEY.
+	KeyProvider KeyProvider
+
+	// tagsOnly reads env vars of fields with an `env` tag only, see BindEnv
+	tagsOnly bool
+
+	validateOnce sync.Once
+	validate     *validator.Validate
+}
+
+// Option configures a Configor created by New
+type Option func(*Configor)
+
+// WithOverrides sets fields by dotted path, see Configor.Overrides
+func WithOverrides(overrides map[string]string) Option {
+	return func(c *Configor) {
+		if c.Overrides == nil {
+			c.Overrides = make(map[string]string, len(overrides))
+		}
+		for k, v := range overrides {
+			c.Overrides[k] = v
+		}
+	}
+}
+
+// WithInterpolation turns on `${...}` expansion in payloads
+func WithInterpolation() Option {
+	return func(c *Configor) {
+		c.Interpolate = true
+	}
+}
+
+// WithEnvironment selects the profile overlays to load, see Configor.Environment
+func WithEnvironment(env string) Option {
+	return func(c *Configor) {
+		c.Environment = env
+	}
+}
+
+// WithStrict rejects unknown keys, see Configor.Strict
+func WithStrict() Option {
+	return func(c *Configor) {
+		c.Strict = true
+	}
+}
+
+// WithUnusedEnv sets what to do about env vars no field reads, see Configor.UnusedEnv
+func WithUnusedEnv(mode UnusedEnv) Option {
+	return func(c *Configor) {
+		c.UnusedEnv = mode
+	}
+}
+
+// WithLogger sets the logger warnings go to
+func WithLogger(l logger.Logger) Option {
+	return func(c *Configor) {
+		c.Logger = l
+	}
+}
+
+// WithKeyProvider sets the key of encrypted values, see Configor.KeyProvider
+func WithKeyProvider(p KeyProvider) Option {
+	return func(c *Configor) {
+		c.KeyProvider = p
+	}
 }
 
 // New initialize a Configor
-func newConfigor() *Configor {
-	return &Configor{
+func New(opts ...Option) *Configor {
+	c := &Configor{
 		EnvPrefix:   strings.ToUpper(os.Getenv("CONFIGOR_ENV_PREFIX")),
+		Environment: os.Getenv("CONFIGOR_ENV"),
 		Unmarshaler: toml.Unmarshal,
 	}
+	if fname := os.Getenv("CONFIGOR_KEY_FILE"); fname != "" {
+		c.KeyProvider = KeyFromFile(fname)
+	} else if os.Getenv("CONFIGOR_KEY") != "" {
+		c.KeyProvider = KeyFromEnv("CONFIGOR_KEY")
+	}
+	for _, f := range opts {
+		f(c)
+	}
+	return c
 }
 
 func (c *Configor) Load(dst any, payload ...[]byte) (err error) {
 	return c.load(dst, payload...)
 }
 
-// Load will unmarshal configurations to struct from files that you provide
+// LoadFile will unmarshal configurations to struct from files that you provide.
+//
+// Files are layered in the given order and deep-merged whatever their
+// format: maps and structs merge key by key, lists and scalars of a later
+// layer replace earlier ones, `null` removes a key. `merge:"append"`
+// concatenates a list instead, `merge:"replace"` replaces a map or struct
+// as a whole. Every file expands to:
+//
+//  1. the files listed under its top level `include` or `$import` key, a
+//     string or a list, relative to the file's directory and expanded the
+//     same way, in the order listed
+//  2. the file itself
+//  3. its profile overlay `<name>.<Environment>.<ext>` if Environment is set
+//     and the overlay exists, expanded like an include
+//
+// A missing include or an include cycle fails the load.
 func (c *Configor) LoadFile(dst any, files ...string) error {
-	return c.loadFile(dst, files...)
+	return c.loadFile(dst, nil, files...)
+}
+
+// LoadFS is LoadFile reading from fsys, like an embed.FS or an
+// fstest.MapFS. Names are slash separated, includes are resolved within
+// fsys relative to the including file.
+func (c *Configor) LoadFS(dst any, fsys fs.FS, names ...string) error {
+	return c.loadFile(dst, fsys, names...)
+}
+
+// LoadReader loads the payload read from r, format picks the unmarshaler
+// like a file extension does, e.g. `yaml` or `.json`. Includes are not
+// followed, there is no directory to resolve them against.
+func (c *Configor) LoadReader(dst any, r io.Reader, format string) error {
+	ext, unmarshaler, err := formatOf(format)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return errors.Wrap(err, "failed to read config")
+	}
+	return c.internalLoad(dst, pair{"reader", data, unmarshaler, ext, ""})
 }
 
 // Load will unmarshal configurations to struct from files that you provide
 func Load(dst any, payload ...[]byte) error {
-	return newConfigor().Load(dst, payload...)
+	return New().Load(dst, payload...)
 }
 
 // Load will unmarshal configurations to struct from files that you provide
 func LoadFile(dst any, files ...string) error {
-	return newConfigor().LoadFile(dst, files...)
+	return New().LoadFile(dst, files...)
+}
+
+// LoadFS will unmarshal configurations to struct from files in fsys
+func LoadFS(dst any, fsys fs.FS, names ...string) error {
+	return New().LoadFS(dst, fsys, names...)
+}
+
+// LoadReader will unmarshal configurations to struct from r in the given format
+func LoadReader(dst any, r io.Reader, format string) error {
+	return New().LoadReader(dst, r, format)
 }
@@ -0,0 +1,379 @@
+package configor
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Encrypted values are strings like `ENC[aes256-gcm,<base64>]`, the base64
+// part holds the nonce followed by the AES-256-GCM sealed value. They may
+// appear in any format, env vars, flags and overrides included, and are
+// decrypted before they are decoded: the plaintext decodes like an env var
+// would, so it may be a number, a duration, a URL or the bytes of a []byte.
+const (
+	encPrefix = "ENC[aes256-gcm,"
+	encSuffix = "]"
+	keySize   = 32
+)
+
+var encPattern = regexp.MustCompile(`ENC\[aes256-gcm,[A-Za-z0-9+/=]*\]`)
+
+// KeyProvider supplies the key encrypted values are decrypted with
+type KeyProvider interface {
+	Key() ([]byte, error)
+}
+
+// KeyProviderFunc adapts a function to a KeyProvider
+type KeyProviderFunc func() ([]byte, error)
+
+func (f KeyProviderFunc) Key() ([]byte, error) { return f() }
+
+// KeyFromFile reads the key from fname, either 32 raw bytes or their
+// base64 encoding
+func KeyFromFile(fname string) KeyProvider {
+	return KeyProviderFunc(func() ([]byte, error) {
+		data, err := os.ReadFile(fname)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read key")
+		}
+		return ParseKey(data)
+	})
+}
+
+// KeyFromEnv reads the base64 encoded key from the env var name
+func KeyFromEnv(name string) KeyProvider {
+	return KeyProviderFunc(func() ([]byte, error) {
+		value := os.Getenv(name)
+		if value == "" {
+			return nil, errors.Errorf("key env %s is not set", name)
+		}
+		return ParseKey([]byte(value))
+	})
+}
+
+// ParseKey accepts a key as 32 raw bytes or their base64 encoding
+func ParseKey(data []byte) ([]byte, error) {
+	if len(data) == keySize {
+		return data, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) != keySize {
+		return nil, errors.Errorf("invalid key, want %d bytes or their base64 encoding", keySize)
+	}
+	return key, nil
+}
+
+// GenerateKey returns a new random key, base64 encoded
+func GenerateKey() (string, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// IsEncrypted reports whether s is an encrypted value
+func IsEncrypted(s string) bool {
+	return strings.HasPrefix(s, encPrefix) && strings.HasSuffix(s, encSuffix)
+}
+
+// Encrypt seals plaintext with key into an `ENC[aes256-gcm,...]` value
+func Encrypt(key []byte, plaintext string) (string, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encPrefix + base64.StdEncoding.EncodeToString(sealed) + encSuffix, nil
+}
+
+// Decrypt opens an `ENC[aes256-gcm,...]` value sealed with key
+func Decrypt(key []byte, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return "", errors.New("not an encrypted value")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimSuffix(strings.TrimPrefix(value, encPrefix), encSuffix))
+	if err != nil {
+		return "", errors.Wrap(err, "invalid encrypted value")
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("invalid encrypted value")
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("failed to decrypt value, wrong key?")
+	}
+	return string(plaintext), nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != keySize {
+		return nil, errors.Errorf("invalid key, want %d bytes", keySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// decrypt opens value if it is encrypted, the key is only asked for then.
+// Errors are recorded at path and reported by ok.
+func (c *Configor) decrypt(state *loadState, path []string, value string) (plaintext string, ok bool) {
+	if !IsEncrypted(value) {
+		return value, true
+	}
+	if state.key == nil {
+		if c.KeyProvider == nil {
+			state.errs.add(path, nil, errors.New("is encrypted, but no KeyProvider is set"))
+			return "", false
+		}
+		key, err := c.KeyProvider.Key()
+		if err != nil {
+			state.errs.add(path, nil, err)
+			return "", false
+		}
+		state.key = key
+	}
+	plaintext, err := Decrypt(state.key, value)
+	if err != nil {
+		state.errs.add(path, nil, err)
+		return "", false
+	}
+	return plaintext, true
+}
+
+// decryptTree decrypts the encrypted strings of a canonical tree in place.
+// Values that fail to decrypt are dropped, the error is recorded.
+func (c *Configor) decryptTree(state *loadState, tree any, path []string) {
+	switch v := tree.(type) {
+	case map[string]any:
+		for k, elem := range v {
+			elemPath := append(path[:len(path):len(path)], k)
+			if s, ok := treeString(elem); ok && IsEncrypted(s) {
+				if plaintext, ok := c.decrypt(state, elemPath, s); ok {
+					v[k] = plainText(plaintext)
+				} else {
+					delete(v, k)
+				}
+				continue
+			}
+			c.decryptTree(state, elem, elemPath)
+		}
+	case []any:
+		for i, elem := range v {
+			elemPath := append(path[:len(path):len(path)], fmt.Sprint(i))
+			if s, ok := treeString(elem); ok && IsEncrypted(s) {
+				v[i] = nil
+				if plaintext, ok := c.decrypt(state, elemPath, s); ok {
+					v[i] = plainText(plaintext)
+				}
+				continue
+			}
+			c.decryptTree(state, elem, elemPath)
+		}
+	}
+}
+
+// processEncrypted decrypts the encrypted strings left in dst, those of
+// payloads that are not decoded into a tree
+func (c *Configor) processEncrypted(dst any, state *loadState) {
+	replaceStrings(reflect.ValueOf(dst), nil, func(path []string, value string) (string, bool) {
+		if !IsEncrypted(value) {
+			return "", false
+		}
+		return c.decrypt(state, path, value)
+	})
+}
+
+// replaceStrings calls replace with the strings below v, map values and
+// interfaces included, and sets those it returns ok for
+func replaceStrings(v reflect.Value, path []string, replace func(path []string, s string) (string, bool)) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			replaceStrings(v.Elem(), path, replace)
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		// the value of an interface is not settable, update a copy
+		elem := reflect.New(v.Elem().Type()).Elem()
+		elem.Set(v.Elem())
+		replaceStrings(elem, path, replace)
+		if v.CanSet() {
+			v.Set(elem)
+		}
+	case reflect.String:
+		if v.CanSet() {
+			if s, ok := replace(path, v.String()); ok {
+				v.SetString(s)
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Type().Field(i); f.IsExported() {
+				replaceStrings(v.Field(i), append(path[:len(path):len(path)], f.Name), replace)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			replaceStrings(v.Index(i), append(path[:len(path):len(path)], fmt.Sprint(i)), replace)
+		}
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			// map values are not addressable, update a copy
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(k))
+			replaceStrings(elem, append(path[:len(path):len(path)], fmt.Sprint(k.Interface())), replace)
+			v.SetMapIndex(k, elem)
+		}
+	}
+}
+
+// RotateEncrypted re-encrypts every encrypted value of a document with
+// newKey, the rest of the document is left untouched
+func RotateEncrypted(data []byte, oldKey, newKey []byte) ([]byte, error) {
+	var err error
+	out := encPattern.ReplaceAllFunc(data, func(value []byte) []byte {
+		if err != nil {
+			return value
+		}
+		var plaintext, rotated string
+		if plaintext, err = Decrypt(oldKey, string(value)); err != nil {
+			return value
+		}
+		if rotated, err = Encrypt(newKey, plaintext); err != nil {
+			return value
+		}
+		return []byte(rotated)
+	})
+	return out, err
+}
+
+// EncryptValues encrypts the string values at the dotted key paths of a
+// YAML, TOML or JSON document, e.g. `db.password`, keys as written in the
+// document. Only the values are rewritten, the rest of the document,
+// comments included, is left untouched. Encrypted values are skipped.
+func EncryptValues(data []byte, format string, key []byte, paths ...string) ([]byte, error) {
+	_, unmarshaler, err := formatOf(format)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]any
+	if err := unmarshaler(data, &doc); err != nil {
+		return nil, err
+	}
+
+	for _, p := range paths {
+		segs := strings.Split(p, ".")
+		var value any = doc
+		for _, seg := range segs {
+			m, ok := value.(map[string]any)
+			if !ok {
+				return nil, errors.Errorf("%s: not found", p)
+			}
+			if value, ok = m[seg]; !ok {
+				return nil, errors.Errorf("%s: not found", p)
+			}
+		}
+		plaintext, ok := value.(string)
+		if !ok {
+			return nil, errors.Errorf("%s: not a string", p)
+		}
+		if IsEncrypted(plaintext) {
+			continue
+		}
+
+		start, end, err := locateValue(data, segs, plaintext)
+		if err != nil {
+			return nil, errors.Wrap(err, p)
+		}
+		encrypted, err := Encrypt(key, plaintext)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data[:start:start], append([]byte(`"`+encrypted+`"`), data[end:]...)...)
+	}
+	return data, nil
+}
+
+// locateValue finds the literal of the value at the key path segs. Keys
+// are searched in order, each after the previous one, which holds for
+// the block styles configuration files are written in.
+func locateValue(data []byte, segs []string, plaintext string) (int, int, error) {
+	off := 0
+	for _, seg := range segs {
+		re := regexp.MustCompile(`(?m)(?:^|[\s{,\[."'])["']?` + regexp.QuoteMeta(seg) + `["']?\s*(?:[:=]|\]\s*$)`)
+		loc := re.FindIndex(data[off:])
+		if loc == nil {
+			return 0, 0, errors.New("key not found")
+		}
+		off += loc[1]
+	}
+
+	for off < len(data) && (data[off] == ' ' || data[off] == '\t') {
+		off++
+	}
+	start, end := off, off
+	switch {
+	case off < len(data) && data[off] == '"':
+		for end = off + 1; end < len(data) && data[end] != '"'; end++ {
+			if data[end] == '\\' {
+				end++
+			}
+		}
+		end++
+	case off < len(data) && data[off] == '\'':
+		end = off + 1 + strings.IndexByte(string(data[off+1:]), '\'') + 1
+	default: // plain YAML scalar
+		for end < len(data) && data[end] != '\n' && !(data[end] == '#' && end > 0 && data[end-1] == ' ') {
+			end++
+		}
+		for end > start && (data[end-1] == ' ' || data[end-1] == '\t' || data[end-1] == '\r') {
+			end--
+		}
+	}
+	if end > len(data) || end <= start {
+		return 0, 0, errors.New("value not found")
+	}
+
+	literal := string(data[start:end])
+	if (literal[0] == '"' || literal[0] == '\'') && (len(literal) < 2 || literal[len(literal)-1] != literal[0]) {
+		return 0, 0, errors.New("value not found")
+	}
+	var value string
+	switch literal[0] {
+	case '"':
+		if err := json.Unmarshal([]byte(literal), &value); err != nil {
+			value = literal[1 : len(literal)-1]
+		}
+	case '\'':
+		value = literal[1 : len(literal)-1]
+	default:
+		value = literal
+	}
+	if value != plaintext {
+		return 0, 0, errors.New("value is not a plain single line string")
+	}
+	return start, end, nil
+}
@@ -0,0 +1,179 @@
+package configor_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cocktail828/go-kits/configor"
+	"github.com/stretchr/testify/assert"
+)
+
+type cryptConfig struct {
+	DB struct {
+		User     string
+		Password string
+	}
+	Tokens map[string]string
+}
+
+func newKey(t *testing.T) []byte {
+	encoded, err := configor.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestEncryptedValues(t *testing.T) {
+	key := newKey(t)
+	password, err := configor.Encrypt(key, "s3cret")
+	assert.NoError(t, err)
+	token, err := configor.Encrypt(key, "t0ken")
+	assert.NoError(t, err)
+
+	dir := t.TempDir()
+	fname := filepath.Join(dir, "config.yaml")
+	writeFile(t, fname, "db:\n  user: root\n  password: "+password+"\ntokens:\n  github: "+token+"\n")
+	keyFile := filepath.Join(dir, "key")
+	writeFile(t, keyFile, base64.StdEncoding.EncodeToString(key)+"\n")
+
+	c := configor.New(configor.WithKeyProvider(configor.KeyFromFile(keyFile)))
+	c.EnvPrefix = "CONFIGOR_CRYPT"
+
+	var cfg cryptConfig
+	assert.NoError(t, c.LoadFile(&cfg, fname))
+	assert.Equal(t, "root", cfg.DB.User)
+	assert.Equal(t, "s3cret", cfg.DB.Password)
+	assert.Equal(t, map[string]string{"github": "t0ken"}, cfg.Tokens)
+
+	// values from env vars are decrypted too
+	t.Setenv("CONFIGOR_CRYPT_DB_USER", password)
+	t.Setenv("CONFIGOR_CRYPT_KEY", base64.StdEncoding.EncodeToString(key))
+	c.KeyProvider = configor.KeyFromEnv("CONFIGOR_CRYPT_KEY")
+	cfg = cryptConfig{}
+	assert.NoError(t, c.LoadFile(&cfg, fname))
+	assert.Equal(t, "s3cret", cfg.DB.User)
+
+	c.KeyProvider = configor.KeyProviderFunc(func() ([]byte, error) { return newKey(t), nil })
+	assert.ErrorContains(t, c.LoadFile(&cfg, fname), "DB.Password: failed to decrypt value")
+
+	c.KeyProvider = nil
+	assert.ErrorContains(t, c.LoadFile(&cfg, fname), "DB.Password: is encrypted, but no KeyProvider is set")
+}
+
+func TestEncryptValues(t *testing.T) {
+	key := newKey(t)
+	docs := map[string]string{
+		"yaml": "# database\ndb:\n  user: root # admin\n  password: s3cret # change me\nname: app\n",
+		"toml": "name = \"app\"\n\n[db]\nuser = \"root\"\npassword = \"s3cret\"\n",
+		"json": "{\n  \"name\": \"app\",\n  \"db\": {\"user\": \"root\", \"password\": \"s3cret\"}\n}\n",
+	}
+	for format, doc := range docs {
+		out, err := configor.EncryptValues([]byte(doc), format, key, "db.password")
+		if !assert.NoError(t, err, format) {
+			continue
+		}
+		assert.NotContains(t, string(out), "s3cret", format)
+		assert.Contains(t, string(out), "root", format)
+
+		// the rest of the document is untouched
+		before, after, _ := strings.Cut(doc, "s3cret")
+		assert.True(t, strings.HasPrefix(string(out), strings.TrimSuffix(strings.TrimSuffix(before, `"`), "'")), format)
+		assert.True(t, strings.HasSuffix(string(out), strings.TrimPrefix(after, `"`)), format)
+
+		var cfg cryptConfig
+		c := configor.New(configor.WithKeyProvider(configor.KeyProviderFunc(func() ([]byte, error) { return key, nil })))
+		c.EnvPrefix = "CONFIGOR_CRYPT"
+		assert.NoError(t, c.LoadReader(&cfg, strings.NewReader(string(out)), format), format)
+		assert.Equal(t, "s3cret", cfg.DB.Password, format)
+
+		newKey := newKey(t)
+		rotated, err := configor.RotateEncrypted(out, key, newKey)
+		assert.NoError(t, err, format)
+		c.KeyProvider = configor.KeyProviderFunc(func() ([]byte, error) { return newKey, nil })
+		cfg = cryptConfig{}
+		assert.NoError(t, c.LoadReader(&cfg, strings.NewReader(string(rotated)), format), format)
+		assert.Equal(t, "s3cret", cfg.DB.Password, format)
+	}
+
+	_, err := configor.EncryptValues([]byte("db:\n  port: 5432\n"), "yaml", key, "db.port")
+	assert.EqualError(t, err, "db.port: not a string")
+}
+
+func TestEncryptedKinds(t *testing.T) {
+	type config struct {
+		Port    int
+		Timeout time.Duration
+		Data    []byte
+		Peer    *url.URL
+		Ports   []int
+		Extra   map[string]any
+	}
+
+	key := newKey(t)
+	enc := func(plaintext string) string {
+		value, err := configor.Encrypt(key, plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return value
+	}
+	want := config{
+		Port:    5432,
+		Timeout: 5 * time.Second,
+		Data:    []byte("raw"),
+		Peer:    &url.URL{Scheme: "postgres", User: url.UserPassword("app", "s3cret"), Host: "db"},
+		Ports:   []int{1, 2},
+		Extra:   map[string]any{"token": "t0ken"},
+	}
+
+	dir := t.TempDir()
+	docs := map[string]string{
+		"config.yaml": "port: " + enc("5432") + "\ntimeout: " + enc("5s") + "\ndata: " + enc("raw") + "\npeer: " + enc("postgres://app:s3cret@db") + "\nports: [1, '" + enc("2") + "']\nextra:\n  token: " + enc("t0ken") + "\n",
+		"config.json": `{"Port": "` + enc("5432") + `", "Timeout": "` + enc("5s") + `", "Data": "` + enc("raw") + `", "Peer": "` + enc("postgres://app:s3cret@db") + `", "Ports": [1, "` + enc("2") + `"], "Extra": {"token": "` + enc("t0ken") + `"}}`,
+	}
+	c := configor.New(configor.WithKeyProvider(configor.KeyProviderFunc(func() ([]byte, error) { return key, nil })))
+	c.EnvPrefix = "CONFIGOR_CRYPT"
+	for name, doc := range docs {
+		fname := filepath.Join(dir, name)
+		writeFile(t, fname, doc)
+		var cfg config
+		if assert.NoError(t, c.LoadFile(&cfg, fname), name) {
+			assert.Equal(t, want, cfg, name)
+		}
+	}
+
+	// env vars and overrides decrypt before they are decoded as well
+	t.Setenv("CONFIGOR_CRYPT_PORT", enc("6543"))
+	c.Overrides = map[string]string{"Timeout": enc("1m")}
+	var cfg config
+	assert.NoError(t, c.Load(&cfg))
+	assert.Equal(t, 6543, cfg.Port)
+	assert.Equal(t, time.Minute, cfg.Timeout)
+
+	// strings in an interface are decrypted after the load too
+	type legacy struct {
+		Extra map[string]any
+	}
+	var l legacy
+	c.Overrides = nil
+	c.Unmarshaler = func(data []byte, v any) error {
+		l, ok := v.(*legacy)
+		if !ok {
+			return errors.New("decodes into legacy only")
+		}
+		l.Extra = map[string]any{"token": enc("t0ken")}
+		return nil
+	}
+	assert.NoError(t, c.Load(&l, []byte("-")))
+	assert.Equal(t, map[string]any{"token": "t0ken"}, l.Extra)
+}
@@ -0,0 +1,413 @@
+package configor
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Origins maps the dotted path of a leaf field, e.g. `DB.Port`, to where
+// its effective value came from: `default tag`, `file config.yaml`,
+// `payload #0` or `env APP_DB_PORT`. Fields left blank have no entry.
+type Origins map[string]string
+
+// Description is an effective configuration together with its origins
+type Description struct {
+	Config  any
+	Origins Origins
+}
+
+// Describe loads files into dst like LoadFile and records the origin of every leaf field
+func (c *Configor) Describe(dst any, files ...string) (*Description, error) {
+	pairs, err := c.readFiles(nil, files...)
+	if err != nil {
+		return nil, err
+	}
+	state := &loadState{origins: Origins{}}
+	if err := c.loadWithState(dst, state, pairs...); err != nil {
+		return nil, err
+	}
+	return &Description{Config: dst, Origins: state.origins}, nil
+}
+
+// Describe loads files into dst like LoadFile and records the origin of every leaf field
+func Describe(dst any, files ...string) (*Description, error) {
+	return New().Describe(dst, files...)
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// isScalar reports whether values of t are printed as a single value
+// even though t is a struct, like time.Time
+func isScalar(t reflect.Type) bool {
+	return t == timeType || t == urlType || t == regexpType || t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType)
+}
+
+func isStruct(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && !isScalar(t)
+}
+
+// walkLeaves calls fn for every leaf below v, structs and slices and maps
+// of structs are walked into, everything else is a leaf. secret is true for
+// leaves at or below a `secret:"true"` field.
+func walkLeaves(v reflect.Value, path []string, secret bool, fn func(path []string, v reflect.Value, secret bool)) {
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	switch {
+	case v.Kind() == reflect.Struct && !isScalar(v.Type()):
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Type().Field(i); f.IsExported() {
+				walkLeaves(v.Field(i), append(path[:len(path):len(path)], f.Name), secret || isSecret(f), fn)
+			}
+		}
+	case (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && isStruct(v.Type().Elem()) && v.Len() > 0:
+		for i := 0; i < v.Len(); i++ {
+			walkLeaves(v.Index(i), append(path[:len(path):len(path)], strconv.Itoa(i)), secret, fn)
+		}
+	case v.Kind() == reflect.Map && isStruct(v.Type().Elem()) && v.Len() > 0:
+		for _, key := range sortedKeys(v) {
+			walkLeaves(v.MapIndex(key), append(path[:len(path):len(path)], fmt.Sprint(key)), secret, fn)
+		}
+	default:
+		fn(path, v, secret)
+	}
+}
+
+// dumpNode is a format independent view of a configuration value
+type dumpNode struct {
+	key    string
+	origin string
+	value  reflect.Value // leaf value, valid if fields and elems are nil
+	fields []*dumpNode   // struct or map of structs
+	elems  []*dumpNode   // slice of structs
+	object bool
+	list   bool
+}
+
+// fieldKey returns the key of f in the given format, following the rules
+// of the format's own encoder
+func fieldKey(f reflect.StructField, format string) (key string, inline bool, skip bool) {
+	tag := f.Tag.Get(format)
+	opts := strings.Split(tag, ",")
+	key = opts[0]
+	if key == "-" && len(opts) == 1 {
+		return "", false, true
+	}
+	for _, opt := range opts[1:] {
+		if opt == "inline" {
+			inline = true
+		}
+	}
+	if key == "" {
+		if f.Anonymous && format != "yaml" && isStruct(f.Type) {
+			inline = true
+		}
+		key = f.Name
+		if format == "yaml" {
+			key = strings.ToLower(f.Name)
+		}
+	}
+	return key, inline, false
+}
+
+// textValue turns values of textOnly types into their text
+func textValue(v reflect.Value) reflect.Value {
+	if !v.IsValid() || !textOnly(v.Type()) || v.Kind() == reflect.Ptr && v.IsNil() {
+		return v
+	}
+	if v.Kind() != reflect.Ptr {
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		v = p
+	}
+	return reflect.ValueOf(fmt.Sprint(v.Interface()))
+}
+
+// recordTree records src as the origin of the leaves of a canonical tree,
+// leaves are found the way walkLeaves finds them
+func recordTree(state *loadState, tree any, t reflect.Type, path []string, src string) {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch v := tree.(type) {
+	case nil:
+	case map[string]any:
+		if t == nil || t.Kind() != reflect.Struct || isScalar(t) {
+			state.record(path, src)
+			return
+		}
+		for k, elem := range v {
+			if f, ok := t.FieldByName(k); ok && len(f.Index) == 1 {
+				recordTree(state, elem, f.Type, append(path[:len(path):len(path)], k), src)
+			}
+		}
+	case []any:
+		if t == nil || (t.Kind() != reflect.Slice && t.Kind() != reflect.Array) || !isStruct(t.Elem()) {
+			state.record(path, src)
+			return
+		}
+		for i, elem := range v {
+			recordTree(state, elem, t.Elem(), append(path[:len(path):len(path)], strconv.Itoa(i)), src)
+		}
+	default:
+		state.record(path, src)
+	}
+}
+
+func buildDump(v reflect.Value, path []string, secret bool, format string, origins Origins) *dumpNode {
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	switch {
+	case v.Kind() == reflect.Struct && !isScalar(v.Type()):
+		node := &dumpNode{object: true}
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			key, inline, skip := fieldKey(f, format)
+			if skip {
+				continue
+			}
+			child := buildDump(v.Field(i), append(path[:len(path):len(path)], f.Name), secret || isSecret(f), format, origins)
+			if inline && child.object {
+				node.fields = append(node.fields, child.fields...)
+				continue
+			}
+			child.key = key
+			node.fields = append(node.fields, child)
+		}
+		return node
+	case (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && isStruct(v.Type().Elem()) && v.Len() > 0:
+		node := &dumpNode{list: true}
+		for i := 0; i < v.Len(); i++ {
+			node.elems = append(node.elems, buildDump(v.Index(i), append(path[:len(path):len(path)], strconv.Itoa(i)), secret, format, origins))
+		}
+		return node
+	case v.Kind() == reflect.Map && isStruct(v.Type().Elem()) && v.Len() > 0:
+		node := &dumpNode{object: true}
+		for _, key := range sortedKeys(v) {
+			child := buildDump(v.MapIndex(key), append(path[:len(path):len(path)], fmt.Sprint(key)), secret, format, origins)
+			child.key = fmt.Sprint(key)
+			node.fields = append(node.fields, child)
+		}
+		return node
+	default:
+		return &dumpNode{value: redact(textValue(v), secret), origin: origins[strings.Join(path, ".")]}
+	}
+}
+
+// Dump writes the effective configuration in format (yaml, json or toml)
+// with every value annotated by its origin. YAML and TOML carry the origin
+// as a trailing comment, JSON leaves become {"value": ..., "origin": ...}.
+// Values of `secret:"true"` fields are masked.
+func (d *Description) Dump(w io.Writer, format string) error {
+	format = strings.TrimPrefix(strings.ToLower(format), ".")
+	if format == "yml" {
+		format = "yaml"
+	}
+
+	root := buildDump(reflect.ValueOf(d.Config), nil, false, format, d.Origins)
+	if !root.object {
+		return errors.New("invalid config, should be struct")
+	}
+
+	switch format {
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		node, err := root.yamlNode()
+		if err != nil {
+			return err
+		}
+		if err := enc.Encode(node); err != nil {
+			return err
+		}
+		return enc.Close()
+	case "json":
+		data, err := json.Marshal(root)
+		if err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, data, "", "  "); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+		_, err = buf.WriteTo(w)
+		return err
+	case "toml":
+		var buf bytes.Buffer
+		if err := root.writeTOML(&buf, nil); err != nil {
+			return err
+		}
+		_, err := buf.WriteTo(w)
+		return err
+	default:
+		return errors.Errorf("unsupported dump format %q", format)
+	}
+}
+
+func (n *dumpNode) yamlNode() (*yaml.Node, error) {
+	switch {
+	case n.object:
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		for _, f := range n.fields {
+			val, err := f.yamlNode()
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: f.key}, val)
+		}
+		return node, nil
+	case n.list:
+		node := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, e := range n.elems {
+			val, err := e.yamlNode()
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, val)
+		}
+		return node, nil
+	default:
+		node := &yaml.Node{}
+		if err := node.Encode(n.value.Interface()); err != nil {
+			return nil, err
+		}
+		node.LineComment = n.origin
+		return node, nil
+	}
+}
+
+func (n *dumpNode) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	switch {
+	case n.object:
+		buf.WriteByte('{')
+		for i, f := range n.fields {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, _ := json.Marshal(f.key)
+			val, err := f.MarshalJSON()
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(key)
+			buf.WriteByte(':')
+			buf.Write(val)
+		}
+		buf.WriteByte('}')
+	case n.list:
+		buf.WriteByte('[')
+		for i, e := range n.elems {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			val, err := e.MarshalJSON()
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(val)
+		}
+		buf.WriteByte(']')
+	default:
+		leaf := struct {
+			Value  any    `json:"value"`
+			Origin string `json:"origin,omitempty"`
+		}{n.value.Interface(), n.origin}
+		return json.Marshal(leaf)
+	}
+	return buf.Bytes(), nil
+}
+
+var bareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func tomlKey(key string) string {
+	if bareKey.MatchString(key) {
+		return key
+	}
+	return strconv.Quote(key)
+}
+
+// tomlValue renders a single value by encoding it as the only key of a table
+func tomlValue(v reflect.Value) (string, bool, error) {
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		return "", false, nil // toml has no null
+	}
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(map[string]any{"v": v.Interface()}); err != nil {
+		return "", false, err
+	}
+	out := strings.TrimSpace(buf.String())
+	if !strings.HasPrefix(out, "v = ") {
+		return "", false, nil // empty tables are left out
+	}
+	return strings.TrimPrefix(out, "v = "), true, nil
+}
+
+func (n *dumpNode) writeTOML(w *bytes.Buffer, keys []string) error {
+	// plain keys must come before any sub table
+	for _, f := range n.fields {
+		if f.object || f.list {
+			continue
+		}
+		val, ok, err := tomlValue(f.value)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(w, "%s = %s", tomlKey(f.key), val)
+		if f.origin != "" {
+			fmt.Fprintf(w, " # %s", f.origin)
+		}
+		w.WriteByte('\n')
+	}
+
+	for _, f := range n.fields {
+		sub := make([]string, 0, len(keys)+1)
+		for _, k := range append(keys, f.key) {
+			sub = append(sub, tomlKey(k))
+		}
+		switch {
+		case f.object:
+			fmt.Fprintf(w, "\n[%s]\n", strings.Join(sub, "."))
+			if err := f.writeTOML(w, append(keys[:len(keys):len(keys)], f.key)); err != nil {
+				return err
+			}
+		case f.list:
+			for _, e := range f.elems {
+				fmt.Fprintf(w, "\n[[%s]]\n", strings.Join(sub, "."))
+				if err := e.writeTOML(w, append(keys[:len(keys):len(keys)], f.key)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
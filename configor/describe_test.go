@@ -0,0 +1,77 @@
+package configor_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cocktail828/go-kits/configor"
+	"github.com/stretchr/testify/assert"
+)
+
+type describeConfig struct {
+	Name string
+	DB   struct {
+		Host string `default:"localhost"`
+		Port int    `default:"3306" yaml:"port" toml:"port" json:"port"`
+		User string
+	}
+	Contacts []struct {
+		Email string
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	fname := t.TempDir() + "/describe.yaml"
+	writeFile(t, fname, "name: app\ndb:\n  port: 3307\ncontacts:\n  - email: a@b.com\n")
+	t.Setenv("CONFIGOR_DESCRIBE_DB_USER", "root")
+
+	var cfg describeConfig
+	c := &configor.Configor{EnvPrefix: "CONFIGOR_DESCRIBE"}
+	d, err := c.Describe(&cfg, fname)
+	if err != nil {
+		t.Fatalf("configor.Describe err:%v", err)
+	}
+	assert.Equal(t, configor.Origins{
+		"Name":             "file " + fname,
+		"DB.Host":          "default tag",
+		"DB.Port":          "file " + fname,
+		"DB.User":          "env CONFIGOR_DESCRIBE_DB_USER",
+		"Contacts.0.Email": "file " + fname,
+	}, d.Origins)
+
+	var buf bytes.Buffer
+	assert.NoError(t, d.Dump(&buf, "yaml"))
+	assert.Equal(t, `name: app # file `+fname+`
+db:
+  host: localhost # default tag
+  port: 3307 # file `+fname+`
+  user: root # env CONFIGOR_DESCRIBE_DB_USER
+contacts:
+  - email: a@b.com # file `+fname+`
+`, buf.String())
+
+	buf.Reset()
+	assert.NoError(t, d.Dump(&buf, "toml"))
+	assert.Equal(t, `Name = "app" # file `+fname+`
+
+[DB]
+Host = "localhost" # default tag
+port = 3307 # file `+fname+`
+User = "root" # env CONFIGOR_DESCRIBE_DB_USER
+
+[[Contacts]]
+Email = "a@b.com" # file `+fname+`
+`, buf.String())
+
+	buf.Reset()
+	assert.NoError(t, d.Dump(&buf, "json"))
+	assert.JSONEq(t, `{
+  "Name": {"value": "app", "origin": "file `+fname+`"},
+  "DB": {
+    "Host": {"value": "localhost", "origin": "default tag"},
+    "port": {"value": 3307, "origin": "file `+fname+`"},
+    "User": {"value": "root", "origin": "env CONFIGOR_DESCRIBE_DB_USER"}
+  },
+  "Contacts": [{"Email": {"value": "a@b.com", "origin": "file `+fname+`"}}]
+}`, buf.String())
+}
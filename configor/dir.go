@@ -0,0 +1,97 @@
+package configor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// LoadDir loads the files of dir, like a `conf.d` directory of fragments,
+// in lexical order. Files without a registered extension, see Register,
+// are skipped or fail the load in Strict mode. Hidden files, like the
+// `..data` entries of a Kubernetes ConfigMap, and the Environment overlays
+// of other files in dir are skipped too, the latter still apply as
+// overlays. Any other dotted name, like `app.v2.yaml`, is a file of its own.
+func (c *Configor) LoadDir(dst any, dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, filepath.Join(dir, e.Name()))
+	}
+
+	files, err := c.configFiles(names)
+	if err != nil {
+		return err
+	}
+	return c.loadFile(dst, nil, files...)
+}
+
+// LoadDir loads the files of dir in lexical order
+func LoadDir(dst any, dir string) error {
+	return New().LoadDir(dst, dir)
+}
+
+// LoadGlob loads the files matching patterns, like `/etc/app/*.yaml`, the
+// matches of a pattern in lexical order. Matches are filtered like the
+// files of LoadDir.
+func (c *Configor) LoadGlob(dst any, patterns ...string) error {
+	var names []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return errors.Wrapf(err, "invalid pattern %q", pattern)
+		}
+		names = append(names, matches...)
+	}
+
+	files, err := c.configFiles(names)
+	if err != nil {
+		return err
+	}
+	return c.loadFile(dst, nil, files...)
+}
+
+// LoadGlob loads the files matching patterns
+func LoadGlob(dst any, patterns ...string) error {
+	return New().LoadGlob(dst, patterns...)
+}
+
+// configFiles picks the config files out of names, see LoadDir
+func (c *Configor) configFiles(names []string) ([]string, error) {
+	var candidates []string
+	known := map[string]bool{}
+	for _, name := range names {
+		if strings.HasPrefix(filepath.Base(name), ".") {
+			continue
+		}
+		if info, err := os.Stat(name); err != nil || info.IsDir() {
+			continue
+		}
+		if _, ok := unmarshalers[filepath.Ext(name)]; !ok {
+			if c.Strict {
+				return nil, errors.Errorf("unsupported config file %s", name)
+			}
+			continue
+		}
+		candidates = append(candidates, name)
+		known[name] = true
+	}
+
+	files := make([]string, 0, len(candidates))
+	for _, name := range candidates {
+		// `config.prod.yaml` is an overlay of `config.yaml` in prod
+		ext := filepath.Ext(name)
+		base := strings.TrimSuffix(name, ext)
+		if i := strings.LastIndex(base, "."); c.Environment != "" && i > len(filepath.Dir(name)) &&
+			base[i+1:] == c.Environment && known[base[:i]+ext] {
+			continue
+		}
+		files = append(files, name)
+	}
+	return files, nil
+}
@@ -0,0 +1,71 @@
+package configor_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/cocktail828/go-kits/configor"
+	"github.com/stretchr/testify/assert"
+)
+
+type dirConfig struct {
+	Name     string
+	Port     int    `default:"80"`
+	Password string `required:"true"`
+	Hosts    []string
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "10-base.yaml"), "name: base\nhosts: [a]\n")
+	writeFile(t, filepath.Join(dir, "20-app.json"), `{"name": "app"}`)
+	writeFile(t, filepath.Join(dir, "20-app.prod.json"), `{"port": 443}`)
+	writeFile(t, filepath.Join(dir, "30-hosts.toml"), "Hosts = [\"b\"]\n")
+	writeFile(t, filepath.Join(dir, "40-db.yaml"), "password: db\n")
+	writeFile(t, filepath.Join(dir, "40-db.v2.yaml"), "hosts: [c]\n")
+	writeFile(t, filepath.Join(dir, "README.md"), "# fragments\n")
+	writeFile(t, filepath.Join(dir, ".hidden.yaml"), "name: hidden\n")
+	writeFile(t, filepath.Join(dir, "sub", "99-ignored.yaml"), "name: ignored\n")
+	t.Setenv("CONFIGOR_DIR_PASSWORD", "secret")
+
+	c := configor.New()
+	c.EnvPrefix = "CONFIGOR_DIR"
+
+	// only the overlays of the Environment are skipped, `40-db.v2.yaml` and
+	// here `20-app.prod.json` are files of their own
+	var cfg dirConfig
+	assert.NoError(t, c.LoadDir(&cfg, dir))
+	assert.Equal(t, dirConfig{Name: "app", Port: 443, Password: "secret", Hosts: []string{"c"}}, cfg)
+
+	cfg = dirConfig{}
+	c.Environment = "test"
+	assert.NoError(t, c.LoadDir(&cfg, dir))
+	assert.Equal(t, dirConfig{Name: "app", Port: 443, Password: "secret", Hosts: []string{"c"}}, cfg)
+
+	cfg = dirConfig{}
+	c.Environment = "prod"
+	writeFile(t, filepath.Join(dir, "20-app.prod.json"), `{"port": 8443}`)
+	assert.NoError(t, c.LoadDir(&cfg, dir))
+	assert.Equal(t, 8443, cfg.Port)
+
+	c.Strict = true
+	assert.EqualError(t, c.LoadDir(&cfg, dir), "unsupported config file "+filepath.Join(dir, "README.md"))
+}
+
+func TestLoadGlob(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "b.yaml"), "name: b\n")
+	writeFile(t, filepath.Join(dir, "a.yaml"), "name: a\nhosts: [a]\n")
+	writeFile(t, filepath.Join(dir, "c.json"), `{"name": "c"}`)
+	t.Setenv("CONFIGOR_DIR_PASSWORD", "secret")
+
+	c := configor.New()
+	c.EnvPrefix = "CONFIGOR_DIR"
+
+	var cfg dirConfig
+	assert.NoError(t, c.LoadGlob(&cfg, filepath.Join(dir, "*.yaml")))
+	assert.Equal(t, "b", cfg.Name)
+	assert.Equal(t, []string{"a"}, cfg.Hosts)
+
+	assert.Error(t, c.LoadGlob(&cfg, filepath.Join(dir, "[")))
+}
@@ -4,52 +4,236 @@ import (
 	"fmt"
 	"os"
 	"reflect"
-	"strconv"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
 )
 
-// BindEnv binds environment variables to struct fields based on 'env' tags
-func BindEnv(in interface{}) error {
-	v := reflect.ValueOf(in)
+// BindEnv sets the fields of dst from env vars alone, the way Load does
+// after the files: a field reads its `env` tag or else the name derived
+// from its path, like `DB_PORT`, both under EnvPrefix, and NAME_FILE
+// names a file to read the value from. Values decode like setValue does,
+// lists also read `NAME_0`, `NAME_1`, ... and maps `NAME_<key>`. The
+// errors of all fields are returned together as a *MultiError.
+func (c *Configor) BindEnv(dst any) error {
+	v := reflect.ValueOf(dst)
 	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
-		return fmt.Errorf("input must be a pointer to a struct")
-	}
-
-	v = v.Elem()
-	t := v.Type()
-
-	for i := 0; i < v.NumField(); i++ {
-		field := v.Field(i)
-		structField := t.Field(i)
-
-		if envName := structField.Tag.Get("env"); envName != "" {
-			if envValue := os.Getenv(envName); envValue != "" {
-				switch field.Kind() {
-				case reflect.String:
-					field.SetString(envValue)
-				case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-					intValue, err := strconv.ParseInt(envValue, 10, 64)
-					if err != nil {
-						return fmt.Errorf("error parsing %s as int64: %v", envName, err)
-					}
-					field.SetInt(intValue)
-				case reflect.Float32, reflect.Float64:
-					floatValue, err := strconv.ParseFloat(envValue, 32)
-					if err != nil {
-						return fmt.Errorf("error parsing %s as float32: %v", envName, err)
-					}
-					field.SetFloat(floatValue)
-				default:
-					return fmt.Errorf("unsupported field type: %s", field.Kind())
-				}
+		return errors.New("input must be a pointer to a struct")
+	}
+
+	state := &loadState{envs: map[string]bool{}}
+	if err := c.processTags(dst, state, nil); err != nil {
+		return err
+	}
+	c.checkUnusedEnv(state)
+	return state.errs.errorOrNil()
+}
+
+// BindEnv binds environment variables to the struct fields with an `env`
+// tag, the tag is the name of the variable as is. Fields without the tag
+// are left alone, so a field `Home` does not pick up $HOME. Values decode
+// like Configor.BindEnv decodes them, use it to read derived names.
+func BindEnv(dst any) error {
+	return (&Configor{tagsOnly: true}).BindEnv(dst)
+}
+
+// envEntry is one element of a list or map set by its own env var
+type envEntry struct {
+	key, value, src string
+}
+
+// lookupEnvEntries reads the entries of a list or map field named name,
+// `name_0`, `name_1`, ... for a list and `name_<key>` for a map. Lists and
+// maps of structs are not read here, nor are types that decode from text.
+func lookupEnvEntries(t reflect.Type, name string, state *loadState, path []string) []envEntry {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if isScalar(t) || (t.Kind() != reflect.Slice && t.Kind() != reflect.Map) || isStruct(t.Elem()) {
+		return nil
+	}
+
+	var keys []string
+	if t.Kind() == reflect.Slice {
+		for i := 0; os.Getenv(fmt.Sprintf("%s_%d", name, i)) != "" || os.Getenv(fmt.Sprintf("%s_%d_FILE", name, i)) != ""; i++ {
+			keys = append(keys, fmt.Sprint(i))
+		}
+	} else {
+		seen := map[string]bool{}
+		for _, kv := range os.Environ() {
+			env, _, _ := strings.Cut(kv, "=")
+			if key := strings.TrimSuffix(strings.TrimPrefix(env, name+"_"), "_FILE"); key != env && key != "" && !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+	}
+
+	var entries []envEntry
+	for _, key := range keys {
+		env := name + "_" + key
+		state.envs[env] = true
+		state.envs[env+"_FILE"] = true
+		value, src, err := lookupEnv(env)
+		if err != nil {
+			state.errs.add(append(path, key), []string{src}, err)
+			continue
+		}
+		if value != "" {
+			entries = append(entries, envEntry{key, value, src})
+		}
+	}
+	return entries
+}
+
+// setEnvEntries sets the entries read by lookupEnvEntries, list elements
+// replace the element at their index, map entries are added to the map
+func setEnvEntries(field reflect.Value, entries []envEntry, state *loadState, path []string) {
+	for field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		field = field.Elem()
+	}
+	if field.Kind() == reflect.Map && field.IsNil() {
+		field.Set(reflect.MakeMap(field.Type()))
+	}
+
+	for i, e := range entries {
+		entryPath := append(path[:len(path):len(path)], e.key)
+		if field.Kind() == reflect.Slice {
+			if i >= field.Len() {
+				field.Set(reflect.Append(field, reflect.Zero(field.Type().Elem())))
 			}
+			if err := setValue(field.Index(i), e.value); err != nil {
+				state.errs.add(entryPath, []string{e.src}, err)
+			} else {
+				state.record(entryPath, e.src)
+			}
+			continue
+		}
+
+		key := reflect.New(field.Type().Key()).Elem()
+		if err := setValue(key, e.key); err != nil {
+			state.errs.add(entryPath, []string{e.src}, err)
+			continue
+		}
+		elem := reflect.New(field.Type().Elem()).Elem()
+		if err := setValue(elem, e.value); err != nil {
+			state.errs.add(entryPath, []string{e.src}, err)
+			continue
 		}
+		field.SetMapIndex(key, elem)
+		state.record(entryPath, e.src)
+	}
+}
+
+// addEnvMapEntries creates the entries of a map of structs that only env
+// sets, DBS_REPLICA_HOST creates the key `replica` of a field DBS. Keys are
+// lower cased and get their `default` tags like the entries of a file.
+func (c *Configor) addEnvMapEntries(field reflect.Value, state *loadState, path, prefixes []string) error {
+	t := field.Type()
+	if c.tagsOnly || t.Key().Kind() != reflect.String || !isStruct(t.Elem()) {
+		return nil
+	}
+
+	name := strings.Join(prefixes, "_")
+	if c.EnvPrefix != "" {
+		name = c.EnvPrefix + "_" + name
+	}
+	bases := []string{name + "_"}
+	if upper := strings.ToUpper(name); upper != name {
+		bases = append(bases, upper+"_")
+	}
+
+	var envs []string
+	for _, kv := range os.Environ() {
+		env, _, _ := strings.Cut(kv, "=")
+		envs = append(envs, env)
+	}
+	sort.Strings(envs)
 
-		if field.Kind() == reflect.Struct {
-			err := BindEnv(field.Addr().Interface())
-			if err != nil {
-				return err
+	for _, env := range envs {
+		for _, base := range bases {
+			if state.envs[env] || !strings.HasPrefix(env, base) {
+				continue
+			}
+			// the key is everything up to one of the underscores that follow
+			rest := env[len(base):]
+			for i := strings.Index(rest, "_"); i > 0; i = nextIndex(rest, "_", i) {
+				key := rest[:i]
+				if hasMapKey(field, key) {
+					break
+				}
+				created, err := c.newEnvMapEntry(field, key, state, path, prefixes)
+				if err != nil {
+					return err
+				}
+				if created {
+					break
+				}
 			}
 		}
 	}
 	return nil
 }
+
+// newEnvMapEntry adds the entry key to a map of structs if env sets any of
+// its fields
+func (c *Configor) newEnvMapEntry(field reflect.Value, key string, state *loadState, path, prefixes []string) (bool, error) {
+	elem := reflect.New(field.Type().Elem()).Elem()
+	target := elem
+	for target.Kind() == reflect.Ptr {
+		target.Set(reflect.New(target.Type().Elem()))
+		target = target.Elem()
+	}
+
+	lower := strings.ToLower(key)
+	entryPath := append(path[:len(path):len(path)], lower)
+	trial := &loadState{sources: state.sources, envs: map[string]bool{}}
+	if state.origins != nil {
+		trial.origins = Origins{}
+	}
+	if err := c.processTags(target.Addr().Interface(), trial, entryPath, append(prefixes, key)...); err != nil {
+		return false, err
+	}
+	if target.IsZero() && len(trial.errs.Errors) == 0 {
+		return false, nil
+	}
+
+	for env := range trial.envs {
+		state.envs[env] = true
+	}
+	state.errs.Errors = append(state.errs.Errors, trial.errs.Errors...)
+	for k, v := range trial.origins {
+		state.origins[k] = v
+	}
+	if err := c.processDefaults(target.Addr().Interface(), state, entryPath...); err != nil {
+		return false, err
+	}
+	if field.IsNil() {
+		field.Set(reflect.MakeMap(field.Type()))
+	}
+	field.SetMapIndex(reflect.ValueOf(lower).Convert(field.Type().Key()), elem)
+	return true, nil
+}
+
+// hasMapKey reports whether the map m has the string key key in any case
+func hasMapKey(m reflect.Value, key string) bool {
+	for _, k := range m.MapKeys() {
+		if strings.EqualFold(k.String(), key) {
+			return true
+		}
+	}
+	return false
+}
+
+// nextIndex is the index of the first sep in s after i, or -1
+func nextIndex(s, sep string, i int) int {
+	if j := strings.Index(s[i+1:], sep); j >= 0 {
+		return i + 1 + j
+	}
+	return -1
+}
@@ -0,0 +1,121 @@
+package configor_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/cocktail828/go-kits/configor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "db"), "s3cret\n")
+	writeFile(t, filepath.Join(dir, "token"), "t0ken\n\n")
+	t.Setenv("CONFIGOR_ENVFILE_DB_PASSWORD_FILE", filepath.Join(dir, "db"))
+	t.Setenv("CONFIGOR_ENVFILE_TOKEN_FILE", filepath.Join(dir, "token"))
+	t.Setenv("ENVFILE_TOKEN_FILE", filepath.Join(dir, "token"))
+	t.Setenv("CONFIGOR_ENVFILE_DB_USER", "root")
+	t.Setenv("CONFIGOR_ENVFILE_DB_USER_FILE", filepath.Join(dir, "db"))
+
+	type config struct {
+		DB struct {
+			User     string
+			Password string `required:"true"`
+		}
+		Token string `env:"TOKEN"`
+	}
+
+	var cfg config
+	c := configor.New()
+	c.EnvPrefix = "CONFIGOR_ENVFILE"
+	c.UnusedEnv = configor.UnusedEnvReject
+	assert.NoError(t, c.Load(&cfg))
+	assert.Equal(t, "root", cfg.DB.User) // the env var itself comes first
+	assert.Equal(t, "s3cret", cfg.DB.Password)
+	assert.Equal(t, "t0ken\n", cfg.Token) // one trailing newline is trimmed
+
+	var bound struct {
+		Token string `env:"ENVFILE_TOKEN"`
+	}
+	assert.NoError(t, configor.BindEnv(&bound))
+	assert.Equal(t, "t0ken\n", bound.Token)
+
+	t.Setenv("CONFIGOR_ENVFILE_DB_PASSWORD_FILE", filepath.Join(dir, "missing"))
+	assert.ErrorContains(t, c.Load(&config{}), "(tried env CONFIGOR_ENVFILE_DB_PASSWORD_FILE)")
+}
+
+func TestBindEnvKinds(t *testing.T) {
+	t.Setenv("CONFIGOR_BIND_DEBUG", "TRUE")
+	t.Setenv("CONFIGOR_BIND_WORKERS", "8")
+	t.Setenv("CONFIGOR_BIND_NAME", "svc")
+	t.Setenv("CONFIGOR_BIND_HOSTS", "a.example.com, b.example.com")
+	t.Setenv("CONFIGOR_BIND_PORTS_0", "80")
+	t.Setenv("CONFIGOR_BIND_PORTS_1", "443")
+	t.Setenv("CONFIGOR_BIND_LABELS_team", "infra")
+	t.Setenv("CONFIGOR_BIND_LABELS_tier", "1")
+	t.Setenv("CONFIGOR_BIND_LIMITS", "cpu=2,mem=4")
+	t.Setenv("CONFIGOR_BIND_DB_PORT", "5432")
+	t.Setenv("CONFIGOR_BIND_DB_TAGS", "[primary, rw]")
+
+	var cfg struct {
+		Debug   bool
+		Workers uint16
+		Name    *string
+		Hosts   []string
+		Ports   []int
+		Labels  map[string]string
+		Limits  map[string]int
+		DB      *struct {
+			Port uint
+			Tags []string
+		}
+	}
+	c := configor.New()
+	c.EnvPrefix = "CONFIGOR_BIND"
+	c.UnusedEnv = configor.UnusedEnvReject
+	assert.NoError(t, c.BindEnv(&cfg))
+	assert.True(t, cfg.Debug)
+	assert.Equal(t, uint16(8), cfg.Workers)
+	if assert.NotNil(t, cfg.Name) {
+		assert.Equal(t, "svc", *cfg.Name)
+	}
+	assert.Equal(t, []string{"a.example.com", "b.example.com"}, cfg.Hosts)
+	assert.Equal(t, []int{80, 443}, cfg.Ports)
+	assert.Equal(t, map[string]string{"team": "infra", "tier": "1"}, cfg.Labels)
+	assert.Equal(t, map[string]int{"cpu": 2, "mem": 4}, cfg.Limits)
+	if assert.NotNil(t, cfg.DB) {
+		assert.Equal(t, uint(5432), cfg.DB.Port)
+		assert.Equal(t, []string{"primary", "rw"}, cfg.DB.Tags)
+	}
+
+	// every bad field is reported, like Load does
+	t.Setenv("CONFIGOR_BIND_WORKERS", "-1")
+	t.Setenv("CONFIGOR_BIND_PORTS_1", "https")
+	err := c.BindEnv(&cfg)
+	var merr *configor.MultiError
+	if assert.ErrorAs(t, err, &merr) {
+		assert.Len(t, merr.Errors, 2)
+	}
+	assert.ErrorContains(t, err, "Workers")
+	assert.ErrorContains(t, err, "Ports.1")
+	assert.ErrorContains(t, err, "CONFIGOR_BIND_PORTS_1")
+
+	assert.Error(t, c.BindEnv(cfg))
+}
+
+func TestBindEnvTagsOnly(t *testing.T) {
+	t.Setenv("HOME", "/home/app")
+	t.Setenv("CONFIGOR_TAGGED_NAME", "svc")
+
+	var cfg struct {
+		Home string
+		Name string `env:"CONFIGOR_TAGGED_NAME"`
+		DB   struct {
+			User string
+		}
+	}
+	assert.NoError(t, configor.BindEnv(&cfg))
+	assert.Equal(t, "", cfg.Home) // untagged fields are left alone
+	assert.Equal(t, "svc", cfg.Name)
+}
@@ -0,0 +1,208 @@
+package configor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// EnvVar is an environment variable a configuration struct reads.
+// Slice elements are numbered from 0, the index shows as `{N}`, the key
+// of a map entry shows as `{KEY}`. Lists and maps of scalars are listed
+// twice, as a whole and by entry, like `HOSTS` and `HOSTS_{N}`.
+type EnvVar struct {
+	// Name is the conventional upper case name
+	Name string `json:"name"`
+	// Aliases are the other names tried before Name
+	Aliases     []string `json:"aliases,omitempty"`
+	Path        string   `json:"path"`
+	Type        string   `json:"type"`
+	Default     any      `json:"default,omitempty"`
+	Required    bool     `json:"required,omitempty"`
+	Secret      bool     `json:"secret,omitempty"`
+	Description string   `json:"description,omitempty"`
+}
+
+// EnvVars lists every environment variable dst reads, see EnvVarsFromSchema
+func (c *Configor) EnvVars(dst any) ([]EnvVar, error) {
+	data, err := c.JSONSchema(dst)
+	if err != nil {
+		return nil, err
+	}
+	return EnvVarsFromSchema(data)
+}
+
+// EnvVars lists every environment variable dst reads
+func EnvVars(dst any) ([]EnvVar, error) {
+	return New().EnvVars(dst)
+}
+
+// EnvVarsFromSchema lists the environment variables of a document made by
+// JSONSchema. Leaves are listed, structs and slices and maps of structs are
+// walked into instead of being listed as a single YAML encoded variable.
+func EnvVarsFromSchema(data []byte) ([]EnvVar, error) {
+	var root schema
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, errors.Wrap(err, "invalid json schema")
+	}
+	vars := []EnvVar{}
+	collectEnvVars(&root, nil, &vars)
+	return vars, nil
+}
+
+func collectEnvVars(s *schema, path []string, vars *[]EnvVar) {
+	keys := make([]string, 0, len(s.Properties))
+	for k := range s.Properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		prop := s.Properties[key]
+		propPath := append(path[:len(path):len(path)], key)
+		switch {
+		case isObject(prop):
+			// empty and recursive structs list nothing
+			collectEnvVars(prop, propPath, vars)
+			continue
+		case prop.Items != nil && isObject(prop.Items):
+			collectEnvVars(prop.Items, append(propPath, "{N}"), vars)
+			continue
+		case prop.AdditionalProperties != nil && isObject(prop.AdditionalProperties):
+			collectEnvVars(prop.AdditionalProperties, append(propPath, "{KEY}"), vars)
+			continue
+		case len(prop.Env) == 0:
+			continue
+		}
+
+		typ := prop.Type
+		if prop.Items != nil && prop.Items.Type != "" {
+			typ += " of " + prop.Items.Type
+		}
+		if prop.AdditionalProperties != nil && prop.AdditionalProperties.Type != "" {
+			typ += " of " + prop.AdditionalProperties.Type
+		}
+		v := EnvVar{
+			Name:        prop.Env[len(prop.Env)-1],
+			Aliases:     prop.Env[:len(prop.Env)-1],
+			Path:        strings.Join(propPath, "."),
+			Type:        typ,
+			Default:     prop.Default,
+			Secret:      prop.WriteOnly,
+			Description: prop.Description,
+		}
+		for _, r := range s.Required {
+			if r == key {
+				v.Required = true
+			}
+		}
+		*vars = append(*vars, v)
+
+		// lists and maps are read one entry per env var as well, see
+		// lookupEnvEntries
+		switch {
+		case prop.Items != nil:
+			*vars = append(*vars, envEntryVar(v, "{N}", prop.Items))
+		case prop.AdditionalProperties != nil:
+			*vars = append(*vars, envEntryVar(v, "{KEY}", prop.AdditionalProperties))
+		}
+	}
+}
+
+// isObject reports whether s describes a struct
+func isObject(s *schema) bool {
+	return s.Type == "object" && s.AdditionalProperties == nil
+}
+
+// envEntryVar is the env var of the entries of the list or map v, seg is
+// `{N}` or `{KEY}`
+func envEntryVar(v EnvVar, seg string, items *schema) EnvVar {
+	aliases := make([]string, len(v.Aliases))
+	for i, alias := range v.Aliases {
+		aliases[i] = alias + "_" + seg
+	}
+	return EnvVar{
+		Name:        v.Name + "_" + seg,
+		Aliases:     aliases,
+		Path:        v.Path + "." + seg,
+		Type:        items.Type,
+		Secret:      v.Secret,
+		Description: v.Description,
+	}
+}
+
+// WriteEnvVars writes vars as `markdown`, `dotenv` (a .env.example file) or `json`
+func WriteEnvVars(w io.Writer, vars []EnvVar, format string) error {
+	var buf bytes.Buffer
+	switch format {
+	case "markdown", "md":
+		buf.WriteString("| Name | Type | Default | Required | Description |\n")
+		buf.WriteString("| ---- | ---- | ------- | -------- | ----------- |\n")
+		for _, v := range vars {
+			names := "`" + v.Name + "`"
+			for _, alias := range v.Aliases {
+				names += "<br>`" + alias + "`"
+			}
+			required := ""
+			if v.Required {
+				required = "yes"
+			}
+			fmt.Fprintf(&buf, "| %s | %s | %s | %s | %s |\n",
+				names, v.Type, markdownCell(v.defaultString()), required, markdownCell(v.Description))
+		}
+	case "dotenv", "env":
+		for i, v := range vars {
+			if i > 0 {
+				buf.WriteByte('\n')
+			}
+			fmt.Fprintf(&buf, "# %s (%s", v.Path, v.Type)
+			if v.Required {
+				buf.WriteString(", required")
+			}
+			buf.WriteString(")\n")
+			if v.Description != "" {
+				fmt.Fprintf(&buf, "# %s\n", v.Description)
+			}
+			value := ""
+			if !v.Secret {
+				value = v.defaultString()
+			}
+			if strings.ContainsAny(value, " #\"'\n") {
+				value = fmt.Sprintf("%q", value)
+			}
+			fmt.Fprintf(&buf, "%s=%s\n", v.Name, value)
+		}
+	case "json":
+		data, err := json.MarshalIndent(vars, "", "  ")
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	default:
+		return errors.Errorf("unsupported env doc format %q", format)
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+func (v EnvVar) defaultString() string {
+	switch d := v.Default.(type) {
+	case nil:
+		return ""
+	case string:
+		return d
+	default:
+		data, _ := json.Marshal(d)
+		return string(data)
+	}
+}
+
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", "\\|", "\n", "<br>").Replace(s)
+}
@@ -0,0 +1,115 @@
+package configor_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/cocktail828/go-kits/configor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvVars(t *testing.T) {
+	type config struct {
+		DB struct {
+			Host     string `default:"localhost" description:"database host"`
+			Password string `required:"true" env:"DBPassword" secret:"true"`
+		}
+		Contacts []struct {
+			Email string
+		}
+		Anonymous `anonymous:"true"`
+	}
+
+	c := &configor.Configor{EnvPrefix: "APP"}
+	vars, err := c.EnvVars(&config{})
+	if err != nil {
+		t.Fatalf("configor.EnvVars err:%v", err)
+	}
+	assert.Equal(t, []configor.EnvVar{
+		{Name: "APP_DESCRIPTION", Aliases: []string{"APP_Description"}, Path: "anonymous.description", Type: "string"},
+		{Name: "APP_CONTACTS_{N}_EMAIL", Aliases: []string{"APP_Contacts_{N}_Email"}, Path: "contacts.{N}.email", Type: "string"},
+		{Name: "APP_DB_HOST", Aliases: []string{"APP_DB_Host"}, Path: "db.host", Type: "string", Default: "localhost", Description: "database host"},
+		{Name: "APP_DBPassword", Aliases: []string{}, Path: "db.password", Type: "string", Required: true, Secret: true},
+	}, vars)
+
+	var buf bytes.Buffer
+	assert.NoError(t, configor.WriteEnvVars(&buf, vars[2:], "dotenv"))
+	assert.Equal(t, `# db.host (string)
+# database host
+APP_DB_HOST=localhost
+
+# db.password (string, required)
+APP_DBPassword=
+`, buf.String())
+
+	buf.Reset()
+	assert.NoError(t, configor.WriteEnvVars(&buf, vars[2:], "markdown"))
+	assert.Equal(t, "| Name | Type | Default | Required | Description |\n"+
+		"| ---- | ---- | ------- | -------- | ----------- |\n"+
+		"| `APP_DB_HOST`<br>`APP_DB_Host` | string | localhost |  | database host |\n"+
+		"| `APP_DBPassword` | string |  | yes |  |\n", buf.String())
+}
+
+func TestEnvVarsMapOfStructs(t *testing.T) {
+	type config struct {
+		DBs map[string]struct {
+			Host string
+		}
+	}
+
+	c := &configor.Configor{EnvPrefix: "APP"}
+	vars, err := c.EnvVars(&config{})
+	if err != nil {
+		t.Fatalf("configor.EnvVars err:%v", err)
+	}
+	assert.Equal(t, []configor.EnvVar{
+		{Name: "APP_DBS_{KEY}_HOST", Aliases: []string{"APP_DBs_{KEY}_Host"}, Path: "dbs.{KEY}.host", Type: "string"},
+	}, vars)
+
+	// the listed name is the one the loader reads
+	t.Setenv(strings.Replace(vars[0].Name, "{KEY}", "REPLICA", 1), "db2")
+	var cfg config
+	assert.NoError(t, c.Load(&cfg))
+	assert.Equal(t, "db2", cfg.DBs["replica"].Host)
+}
+
+type envDocNode struct {
+	Name     string
+	Children []envDocNode
+	Parent   *envDocNode
+}
+
+func TestEnvVarsEntries(t *testing.T) {
+	type config struct {
+		Hosts  []string
+		Labels map[string]string
+		Empty  struct{}
+		Tree   envDocNode
+	}
+
+	c := &configor.Configor{EnvPrefix: "APP"}
+	vars, err := c.EnvVars(&config{})
+	if err != nil {
+		t.Fatalf("configor.EnvVars err:%v", err)
+	}
+	assert.Equal(t, []configor.EnvVar{
+		{Name: "APP_HOSTS", Aliases: []string{"APP_Hosts"}, Path: "hosts", Type: "array of string"},
+		{Name: "APP_HOSTS_{N}", Aliases: []string{"APP_Hosts_{N}"}, Path: "hosts.{N}", Type: "string"},
+		{Name: "APP_LABELS", Aliases: []string{"APP_Labels"}, Path: "labels", Type: "object of string"},
+		{Name: "APP_LABELS_{KEY}", Aliases: []string{"APP_Labels_{KEY}"}, Path: "labels.{KEY}", Type: "string"},
+		{Name: "APP_TREE_NAME", Aliases: []string{"APP_Tree_Name"}, Path: "tree.name", Type: "string"},
+	}, vars)
+
+	// the listed names are the ones the loader reads
+	t.Setenv(strings.Replace(vars[1].Name, "{N}", "0", 1), "a")
+	t.Setenv(strings.Replace(vars[1].Name, "{N}", "1", 1), "b")
+	t.Setenv(strings.Replace(vars[3].Name, "{KEY}", "team", 1), "core")
+	var cfg struct {
+		Hosts  []string
+		Labels map[string]string
+	}
+	assert.NoError(t, c.Load(&cfg))
+	assert.Equal(t, []string{"a", "b"}, cfg.Hosts)
+	assert.Equal(t, map[string]string{"team": "core"}, cfg.Labels)
+}
@@ -0,0 +1,85 @@
+package configor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrRequired is the cause of a FieldError for a blank `required:"true"` field
+var ErrRequired = errors.New("is required, but not set")
+
+// UnknownFieldError reports the keys of a source that no field decodes
+// in Strict mode
+type UnknownFieldError struct {
+	// Source is the source the keys were found in, e.g. `file config.yaml`
+	Source string
+	// Keys are the dotted paths of the unknown keys, with the line for
+	// YAML and JSON, e.g. `line 3: db.pasword` or `db.pasword`
+	Keys []string
+}
+
+func (e *UnknownFieldError) Error() string {
+	return fmt.Sprintf("unknown keys in %s: %s", e.Source, strings.Join(e.Keys, ", "))
+}
+
+// FieldError describes a problem with a single configuration field
+type FieldError struct {
+	// Path is the dotted path of the field, e.g. `Contacts.0.Email`
+	Path string
+	// Sources lists what was tried for the field, such as `file config.yaml`,
+	// `env APP_DB_PORT` or `default tag`
+	Sources []string
+	// Err is the cause
+	Err error
+}
+
+func (e *FieldError) Error() string {
+	if e.Path == "" {
+		return e.Err.Error()
+	}
+	if len(e.Sources) == 0 {
+		return fmt.Sprintf("%s: %v", e.Path, e.Err)
+	}
+	return fmt.Sprintf("%s: %v (tried %s)", e.Path, e.Err, strings.Join(e.Sources, ", "))
+}
+
+func (e *FieldError) Unwrap() error { return e.Err }
+
+// MultiError collects every FieldError found by a load
+type MultiError struct {
+	Errors []*FieldError
+}
+
+func (e *MultiError) add(path []string, sources []string, err error) {
+	e.Errors = append(e.Errors, &FieldError{
+		Path:    strings.Join(path, "."),
+		Sources: sources,
+		Err:     err,
+	})
+}
+
+func (e *MultiError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		msgs = append(msgs, fe.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (e *MultiError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		errs = append(errs, fe)
+	}
+	return errs
+}
+
+// errorOrNil keeps a nil *MultiError from turning into a non-nil error
+func (e *MultiError) errorOrNil() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
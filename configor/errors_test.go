@@ -0,0 +1,50 @@
+package configor_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cocktail828/go-kits/configor"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMultiError(t *testing.T) {
+	type config struct {
+		Name string `required:"true"`
+		DB   struct {
+			Password string `required:"true" env:"DBPassword"`
+			Port     int    `default:"port"`
+		}
+		Contacts []struct {
+			Email string `required:"true"`
+		}
+	}
+
+	fname := "test/config.yaml"
+	c := &configor.Configor{EnvPrefix: "CONFIGOR_ERRORS", Unmarshaler: yaml.Unmarshal}
+	t.Setenv("CONFIGOR_ERRORS_CONTACTS_1_EMAIL", "a@b.com")
+	err := c.LoadFile(&config{Contacts: make([]struct {
+		Email string `required:"true"`
+	}, 2)}, fname)
+
+	var merr *configor.MultiError
+	if !errors.As(err, &merr) {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	paths := []string{}
+	for _, fe := range merr.Errors {
+		paths = append(paths, fe.Path)
+	}
+	assert.Equal(t, []string{"DB.Port", "Name", "DB.Password", "Contacts.0.Email"}, paths)
+
+	assert.Equal(t, []string{"default tag"}, merr.Errors[0].Sources)
+	assert.Equal(t, []string{"file " + fname, "env CONFIGOR_ERRORS_Name, CONFIGOR_ERRORS_NAME"}, merr.Errors[1].Sources)
+	assert.Equal(t, []string{"file " + fname, "env CONFIGOR_ERRORS_DBPassword"}, merr.Errors[2].Sources)
+	assert.ErrorIs(t, merr.Errors[1], configor.ErrRequired)
+
+	var ferr *configor.FieldError
+	assert.True(t, errors.As(err, &ferr))
+	assert.Equal(t, "DB.Port", ferr.Path)
+}
@@ -0,0 +1,120 @@
+package configor
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// FlagSet is the command line layer of a Configor, flags given on the
+// command line override env vars. StdFlagSet adapts a *flag.FlagSet, other
+// flag packages such as pflag only need a small adapter.
+type FlagSet interface {
+	// Define registers a flag, boolean flags may be given without a value
+	Define(name, usage, value string, isBool bool)
+	// Lookup returns the value of a flag that was given on the command line
+	Lookup(name string) (value string, ok bool)
+}
+
+// flagValue satisfies both flag.Value and pflag.Value
+type flagValue struct {
+	value  string
+	isBool bool
+}
+
+func (v *flagValue) String() string     { return v.value }
+func (v *flagValue) Set(s string) error { v.value = s; return nil }
+func (v *flagValue) IsBoolFlag() bool   { return v.isBool }
+func (v *flagValue) Type() string {
+	if v.isBool {
+		return "bool"
+	}
+	return "string"
+}
+
+type stdFlagSet struct {
+	fs *flag.FlagSet
+}
+
+// StdFlagSet adapts a standard library flag set, flags defined by the
+// application itself are read as well if their names match
+func StdFlagSet(fs *flag.FlagSet) FlagSet {
+	return stdFlagSet{fs}
+}
+
+func (s stdFlagSet) Define(name, usage, value string, isBool bool) {
+	s.fs.Var(&flagValue{value: value, isBool: isBool}, name, usage)
+}
+
+func (s stdFlagSet) Lookup(name string) (value string, ok bool) {
+	s.fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			value, ok = f.Value.String(), true
+		}
+	})
+	return value, ok
+}
+
+// flagName returns the flag of a field, the `flag` tag or the lower cased
+// env name joined by `-`, e.g. `db-port`. It is empty for `flag:"-"`.
+func (c *Configor) flagName(prefixes []string, fieldStruct *reflect.StructField) string {
+	switch name := fieldStruct.Tag.Get("flag"); name {
+	case "-":
+		return ""
+	case "":
+		return strings.ToLower(strings.Join(append(prefixes[:len(prefixes):len(prefixes)], fieldStruct.Name), "-"))
+	default:
+		return name
+	}
+}
+
+// RegisterFlags defines a flag on c.FlagSet for every leaf of dst, the
+// `default` and `description` tags make up the help text. Call it before
+// the flag set is parsed, the parsed flags apply on every following load.
+func (c *Configor) RegisterFlags(dst any) error {
+	if c.FlagSet == nil {
+		return errors.New("configor: FlagSet is not set")
+	}
+	t := reflect.TypeOf(dst)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return errors.New("invalid dst, should be struct")
+	}
+	c.registerFlags(t, nil)
+	return nil
+}
+
+func (c *Configor) registerFlags(t reflect.Type, prefixes []string) {
+	for i := 0; i < t.NumField(); i++ {
+		fieldStruct := t.Field(i)
+		if !fieldStruct.IsExported() {
+			continue
+		}
+
+		ft := fieldStruct.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if isStruct(ft) {
+			c.registerFlags(ft, c.getPrefixForStruct(prefixes, &fieldStruct))
+			continue
+		}
+		if (ft.Kind() == reflect.Slice || ft.Kind() == reflect.Array) && isStruct(ft.Elem()) {
+			continue // elements have no fixed names
+		}
+
+		name := c.flagName(prefixes, &fieldStruct)
+		if name == "" {
+			continue
+		}
+		usage := fieldStruct.Tag.Get("description")
+		if fieldStruct.Tag.Get("required") == "true" {
+			usage = strings.TrimSpace(usage + " (required)")
+		}
+		c.FlagSet.Define(name, usage, fieldStruct.Tag.Get("default"), ft.Kind() == reflect.Bool)
+	}
+}
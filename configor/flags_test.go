@@ -0,0 +1,45 @@
+package configor_test
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+
+	"github.com/cocktail828/go-kits/configor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlags(t *testing.T) {
+	type config struct {
+		Name string `required:"true" description:"service name"`
+		DB   struct {
+			Host string `default:"localhost" flag:"db-addr"`
+			Port int    `default:"3306"`
+			SSL  bool
+		}
+		Debug bool `flag:"-"`
+	}
+
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	c := &configor.Configor{EnvPrefix: "CONFIGOR_FLAGS", FlagSet: configor.StdFlagSet(fs)}
+	assert.NoError(t, c.RegisterFlags(&config{}))
+	assert.Nil(t, fs.Lookup("debug"))
+
+	var help bytes.Buffer
+	fs.SetOutput(&help)
+	fs.PrintDefaults()
+	assert.Contains(t, help.String(), "-db-addr value\n    \t (default localhost)")
+	assert.Contains(t, help.String(), "-name value\n    \tservice name (required)")
+	assert.Contains(t, help.String(), "-db-ssl\n")
+
+	t.Setenv("CONFIGOR_FLAGS_DB_PORT", "3307")
+	t.Setenv("CONFIGOR_FLAGS_NAME", "env")
+	assert.NoError(t, fs.Parse([]string{"-name", "flag", "-db-addr", "db.local", "-db-ssl"}))
+
+	var cfg config
+	assert.NoError(t, c.Load(&cfg))
+	assert.Equal(t, "flag", cfg.Name)
+	assert.Equal(t, "db.local", cfg.DB.Host)
+	assert.Equal(t, 3307, cfg.DB.Port)
+	assert.True(t, cfg.DB.SSL)
+}
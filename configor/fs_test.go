@@ -0,0 +1,47 @@
+package configor_test
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/cocktail828/go-kits/configor"
+	"github.com/stretchr/testify/assert"
+)
+
+type fsConfig struct {
+	Name  string
+	Port  int `default:"80"`
+	Hosts []string
+}
+
+func TestLoadFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"conf/base.toml":        {Data: []byte("Hosts = [\"a\"]\n")},
+		"conf/config.yaml":      {Data: []byte("include: base.toml\nname: app\n")},
+		"conf/config.prod.yaml": {Data: []byte("port: 443\n")},
+	}
+
+	var cfg fsConfig
+	c := configor.New(configor.WithEnvironment("prod"))
+	c.EnvPrefix = "CONFIGOR_FS"
+	assert.NoError(t, c.LoadFS(&cfg, fsys, "conf/config.yaml"))
+	assert.Equal(t, fsConfig{Name: "app", Port: 443, Hosts: []string{"a"}}, cfg)
+
+	assert.Error(t, c.LoadFS(&cfg, fsys, "conf/missing.yaml"))
+}
+
+func TestLoadReader(t *testing.T) {
+	c := configor.New()
+	c.EnvPrefix = "CONFIGOR_FS"
+
+	var cfg fsConfig
+	assert.NoError(t, c.LoadReader(&cfg, strings.NewReader(`{"name": "app", "hosts": ["a", "b"]}`), "json"))
+	assert.Equal(t, fsConfig{Name: "app", Port: 80, Hosts: []string{"a", "b"}}, cfg)
+
+	cfg = fsConfig{}
+	assert.NoError(t, c.LoadReader(&cfg, strings.NewReader("name: app\n"), ".yml"))
+	assert.Equal(t, "app", cfg.Name)
+
+	assert.EqualError(t, c.LoadReader(&cfg, strings.NewReader(""), "ini"), `unsupported format "ini"`)
+}
@@ -0,0 +1,105 @@
+package configor
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Defaulter is implemented by config types that compute their defaults,
+// like a Port that depends on SSL. SetDefaults runs once files, env,
+// flags and overrides are applied, before the required checks, a parent
+// before its fields. It should only fill fields that are still blank.
+type Defaulter interface {
+	SetDefaults()
+}
+
+// Validator is implemented by config types with checks that span fields.
+// Validate runs after the `validate` tags, the fields of a struct before
+// the struct itself, and its error is reported with the struct's path.
+type Validator interface {
+	Validate() error
+}
+
+// PostLoader is implemented by config types that derive state from the
+// loaded values. AfterLoad runs last and only if the load succeeded
+// otherwise, the fields of a struct before the struct itself.
+type PostLoader interface {
+	AfterLoad() error
+}
+
+// runDefaulters calls SetDefaults on dst and everything below it
+func runDefaulters(dst any) {
+	walkStructs(reflect.ValueOf(dst), nil, true, true, func(v any, _ []string) {
+		if d, ok := v.(Defaulter); ok {
+			d.SetDefaults()
+		}
+	})
+}
+
+// runValidators calls Validate on dst and everything below it and records
+// the errors
+func runValidators(dst any, state *loadState) {
+	walkStructs(reflect.ValueOf(dst), nil, true, false, func(v any, path []string) {
+		if val, ok := v.(Validator); ok {
+			if err := val.Validate(); err != nil {
+				state.errs.add(path, nil, err)
+			}
+		}
+	})
+}
+
+// runPostLoaders calls AfterLoad on dst and everything below it and
+// records the errors
+func runPostLoaders(dst any, state *loadState) {
+	walkStructs(reflect.ValueOf(dst), nil, true, false, func(v any, path []string) {
+		if p, ok := v.(PostLoader); ok {
+			if err := p.AfterLoad(); err != nil {
+				state.errs.add(path, nil, err)
+			}
+		}
+	})
+}
+
+// walkStructs calls fn with a pointer to every struct at or below v, in
+// fields, slice elements and map values, along with its path. parentFirst
+// picks whether a struct comes before or after its fields. self is false
+// for embedded structs, they are walked into but not passed to fn as
+// their methods are the embedding struct's.
+func walkStructs(v reflect.Value, path []string, self, parentFirst bool, fn func(v any, path []string)) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if isScalar(v.Type()) || !v.CanAddr() {
+			return
+		}
+		if self && parentFirst {
+			fn(v.Addr().Interface(), path)
+		}
+		for i := 0; i < v.NumField(); i++ {
+			fieldStruct := v.Type().Field(i)
+			if !v.Field(i).CanInterface() {
+				continue
+			}
+			fieldPath := append(path[:len(path):len(path)], fieldStruct.Name)
+			walkStructs(v.Field(i), fieldPath, !fieldStruct.Anonymous, parentFirst, fn)
+		}
+		if self && !parentFirst {
+			fn(v.Addr().Interface(), path)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			walkStructs(v.Index(i), append(path[:len(path):len(path)], fmt.Sprint(i)), true, parentFirst, fn)
+		}
+	case reflect.Map:
+		eachMapStruct(v, func(key string, elem reflect.Value) error {
+			walkStructs(elem, append(path[:len(path):len(path)], key), true, parentFirst, fn)
+			return nil
+		})
+	}
+}
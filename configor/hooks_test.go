@@ -0,0 +1,83 @@
+package configor_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cocktail828/go-kits/configor"
+	"github.com/stretchr/testify/assert"
+)
+
+type hookServer struct {
+	SSL    bool
+	Port   int
+	loaded bool
+}
+
+func (s *hookServer) SetDefaults() {
+	if s.Port == 0 {
+		s.Port = 80
+		if s.SSL {
+			s.Port = 443
+		}
+	}
+}
+
+func (s *hookServer) Validate() error {
+	if s.SSL && s.Port == 80 {
+		return errors.New("SSL on port 80")
+	}
+	return nil
+}
+
+func (s *hookServer) AfterLoad() error {
+	s.loaded = true
+	return nil
+}
+
+type hookConfig struct {
+	Main    hookServer
+	Backups []hookServer
+	Named   map[string]*hookServer
+	calls   []string
+}
+
+func (c *hookConfig) SetDefaults() {
+	c.calls = append(c.calls, "SetDefaults")
+}
+
+func (c *hookConfig) Validate() error {
+	c.calls = append(c.calls, "Validate")
+	return nil
+}
+
+func (c *hookConfig) AfterLoad() error {
+	c.calls = append(c.calls, "AfterLoad")
+	if !c.Main.loaded {
+		return errors.New("fields are loaded after their struct")
+	}
+	return nil
+}
+
+func TestHooks(t *testing.T) {
+	var cfg hookConfig
+	err := configor.New().LoadReader(&cfg, strings.NewReader(`{"main": {"ssl": true}, "backups": [{"port": 8080}, {}], "named": {"edge": {"ssl": true}}}`), "json")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"SetDefaults", "Validate", "AfterLoad"}, cfg.calls)
+	assert.Equal(t, 443, cfg.Main.Port)
+	assert.Equal(t, 8080, cfg.Backups[0].Port)
+	assert.Equal(t, 80, cfg.Backups[1].Port)
+	assert.Equal(t, 443, cfg.Named["edge"].Port)
+	assert.True(t, cfg.Backups[1].loaded)
+	assert.True(t, cfg.Named["edge"].loaded)
+
+	cfg = hookConfig{}
+	err = configor.New().LoadReader(&cfg, strings.NewReader(`{"main": {"ssl": true, "port": 80}, "backups": [{"ssl": true, "port": 80}]}`), "json")
+	var merr *configor.MultiError
+	if assert.ErrorAs(t, err, &merr) && assert.Len(t, merr.Errors, 2) {
+		assert.Equal(t, "Main", merr.Errors[0].Path)
+		assert.Equal(t, "Backups.0", merr.Errors[1].Path)
+	}
+	assert.Equal(t, []string{"SetDefaults", "Validate"}, cfg.calls) // no AfterLoad once it failed
+}
@@ -0,0 +1,116 @@
+package configor
+
+import (
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var varName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// interpolate expands `${VAR}`, `${VAR:-default}` and `${file:/path}` in
+// the string s, `$${` is a literal `${`. Any other `$` is left alone. An
+// undefined VAR without a default is an error, `${VAR:-}` opts out to "".
+func (c *Configor) interpolate(s string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	var buf strings.Builder
+	for rest := s; len(rest) > 0; {
+		i := strings.IndexByte(rest, '$')
+		if i < 0 {
+			buf.WriteString(rest)
+			break
+		}
+		buf.WriteString(rest[:i])
+		rest = rest[i:]
+
+		switch {
+		case strings.HasPrefix(rest, "$${"):
+			buf.WriteString("${")
+			rest = rest[3:]
+		case strings.HasPrefix(rest, "${"):
+			end := strings.IndexByte(rest, '}')
+			if end < 0 {
+				return "", errors.New("unterminated ${")
+			}
+			value, err := c.expand(rest[2:end])
+			if err != nil {
+				return "", err
+			}
+			buf.WriteString(value)
+			rest = rest[end+1:]
+		default:
+			buf.WriteByte('$')
+			rest = rest[1:]
+		}
+	}
+	return buf.String(), nil
+}
+
+// interpolateTree interpolates the string values of a canonical tree in
+// place, keys and comments are left alone. Values are expanded after they
+// are decoded, so they need no quoting, and expanded values decode like an
+// env var: `port: "${PORT}"` fills an int.
+func (c *Configor) interpolateTree(tree any, path []string) error {
+	expand := func(elem any, path []string) (any, error) {
+		s, ok := treeString(elem)
+		if !ok || !strings.Contains(s, "${") {
+			return elem, c.interpolateTree(elem, path)
+		}
+		value, err := c.interpolate(s)
+		if err != nil {
+			return nil, errors.Wrap(err, strings.Join(path, "."))
+		}
+		return plainText(value), nil
+	}
+
+	switch v := tree.(type) {
+	case map[string]any:
+		for k, elem := range v {
+			value, err := expand(elem, append(path[:len(path):len(path)], k))
+			if err != nil {
+				return err
+			}
+			v[k] = value
+		}
+	case []any:
+		for i, elem := range v {
+			value, err := expand(elem, append(path[:len(path):len(path)], strconv.Itoa(i)))
+			if err != nil {
+				return err
+			}
+			v[i] = value
+		}
+	}
+	return nil
+}
+
+func (c *Configor) expand(expr string) (string, error) {
+	if fname, ok := strings.CutPrefix(expr, "file:"); ok {
+		data, err := os.ReadFile(fname)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(string(data), "\n"), nil
+	}
+
+	name, def, hasDefault := strings.Cut(expr, ":-")
+	if !varName.MatchString(name) {
+		return "", errors.Errorf("invalid variable ${%s}", expr)
+	}
+	if value := os.Getenv(name); value != "" {
+		return value, nil
+	}
+	if hasDefault {
+		return def, nil
+	}
+	if _, ok := os.LookupEnv(name); !ok {
+		return "", errors.Errorf("variable %s is not defined, use ${%s:-} for an empty value", name, name)
+	}
+	return "", nil
+}
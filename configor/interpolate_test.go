@@ -0,0 +1,91 @@
+package configor_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/cocktail828/go-kits/configor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterpolate(t *testing.T) {
+	type config struct {
+		DSN      string
+		Password string
+		Port     int
+		Price    string
+	}
+
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "db")
+	writeFile(t, secret, "s3cret\n")
+	t.Setenv("CONFIGOR_INTERPOLATE_USER", "admin")
+
+	payloads := map[string]string{
+		"config.yaml": "dsn: postgres://${CONFIGOR_INTERPOLATE_USER}@host\npassword: ${file:" + secret + "}\nport: ${CONFIGOR_INTERPOLATE_PORT:-5432}\nprice: $$${CONFIGOR_INTERPOLATE_UNSET}5\n",
+		"config.toml": "DSN = \"postgres://${CONFIGOR_INTERPOLATE_USER}@host\"\nPassword = \"${file:" + secret + "}\"\nPort = \"${CONFIGOR_INTERPOLATE_PORT:-5432}\"\nPrice = \"$$${CONFIGOR_INTERPOLATE_UNSET}5\"\n",
+		"config.json": `{"DSN": "postgres://${CONFIGOR_INTERPOLATE_USER}@host", "Password": "${file:` + secret + `}", "Port": "${CONFIGOR_INTERPOLATE_PORT:-5432}", "Price": "$$${CONFIGOR_INTERPOLATE_UNSET}5"}`,
+	}
+	for name, body := range payloads {
+		fname := filepath.Join(dir, name)
+		writeFile(t, fname, body)
+
+		var cfg config
+		c := configor.New(configor.WithInterpolation())
+		c.EnvPrefix = "CONFIGOR_INTERPOLATE"
+		if assert.NoError(t, c.LoadFile(&cfg, fname), name) {
+			assert.Equal(t, config{DSN: "postgres://admin@host", Password: "s3cret", Port: 5432, Price: "$${CONFIGOR_INTERPOLATE_UNSET}5"}, cfg, name)
+		}
+	}
+}
+
+func TestInterpolateErrors(t *testing.T) {
+	type config struct {
+		Name string
+	}
+	c := configor.New(configor.WithInterpolation())
+	c.EnvPrefix = "CONFIGOR_INTERPOLATE"
+	assert.ErrorContains(t, c.Load(&config{}, []byte("Name = \"${CONFIGOR_INTERPOLATE_UNSET}\"")), "Name: variable CONFIGOR_INTERPOLATE_UNSET is not defined")
+	assert.ErrorContains(t, c.Load(&config{}, []byte("\nName = \"${CONFIGOR_INTERPOLATE_UNSET\"")), "Name: unterminated ${")
+	assert.NoError(t, c.Load(&config{}, []byte("Name = \"${CONFIGOR_INTERPOLATE_UNSET:-x}\"")))
+
+	// undefined is an error by default, an empty default opts out
+	var empty config
+	assert.ErrorContains(t, c.Load(&empty, []byte("Name = \"a${CONFIGOR_INTERPOLATE_UNSET}\"")), "use ${CONFIGOR_INTERPOLATE_UNSET:-} for an empty value")
+	assert.NoError(t, c.Load(&empty, []byte("Name = \"a${CONFIGOR_INTERPOLATE_UNSET:-}\"")))
+	assert.Equal(t, "a", empty.Name)
+
+	// expansion is opt-in
+	var cfg config
+	assert.NoError(t, (&configor.Configor{EnvPrefix: "CONFIGOR_INTERPOLATE", Unmarshaler: toml.Unmarshal}).Load(&cfg, []byte("Name = \"${HOME}\"")))
+	assert.Equal(t, "${HOME}", cfg.Name)
+}
+
+func TestInterpolateValuesOnly(t *testing.T) {
+	type config struct {
+		Name  string
+		Token string
+	}
+	t.Setenv("CONFIGOR_IVALUE_DIR", "conf")
+	t.Setenv("CONFIGOR_IVALUE_TOKEN", "a\"b\nc")
+
+	dir := t.TempDir()
+	c := configor.New(configor.WithInterpolation())
+	c.EnvPrefix = "CONFIGOR_INTERPOLATE"
+
+	// comments are not expanded, values need no escaping
+	fname := filepath.Join(dir, "config.yaml")
+	writeFile(t, fname, "# name: ${CONFIGOR_INTERPOLATE_UNSET}\nname: ${CONFIGOR_IVALUE_DIR}\ntoken: ${CONFIGOR_IVALUE_TOKEN} # ${CONFIGOR_INTERPOLATE_UNSET}\n")
+	var cfg config
+	assert.NoError(t, c.LoadFile(&cfg, fname))
+	assert.Equal(t, config{Name: "conf", Token: "a\"b\nc"}, cfg)
+
+	// include paths are expanded
+	writeFile(t, filepath.Join(dir, "conf", "base.yaml"), "name: base\ntoken: t\n")
+	fname = filepath.Join(dir, "include.yaml")
+	writeFile(t, fname, "include: ${CONFIGOR_IVALUE_DIR}/base.yaml\n")
+	cfg = config{}
+	assert.NoError(t, c.LoadFile(&cfg, fname))
+	assert.Equal(t, config{Name: "base", Token: "t"}, cfg)
+}
@@ -0,0 +1,65 @@
+package configor_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/cocktail828/go-kits/configor"
+	"github.com/stretchr/testify/assert"
+)
+
+type mapDBConfig struct {
+	Host     string `required:"true"`
+	Port     int    `default:"5432"`
+	Password string
+}
+
+type mapConfig struct {
+	DBs    map[string]mapDBConfig
+	Caches map[string]*mapDBConfig
+}
+
+func TestMapFields(t *testing.T) {
+	dir := t.TempDir()
+	fname := filepath.Join(dir, "config.yaml")
+	writeFile(t, fname, "dbs:\n  primary:\n    host: db1\n  replica:\n    host: db2\n    port: 6432\ncaches:\n  local:\n    host: localhost\n")
+	t.Setenv("CONFIGOR_MAP_DBS_PRIMARY_PASSWORD", "s3cret")
+	t.Setenv("CONFIGOR_MAP_DBS_REPLICA_PORT", "7432")
+	t.Setenv("CONFIGOR_MAP_DBS_ANALYTICS_1_HOST", "db3")
+	t.Setenv("CONFIGOR_MAP_CACHES_LOCAL_PORT", "6379")
+	t.Setenv("CONFIGOR_MAP_CACHES_SHARED_HOST", "cache")
+
+	var cfg mapConfig
+	c := configor.New()
+	c.EnvPrefix = "CONFIGOR_MAP"
+	c.UnusedEnv = configor.UnusedEnvReject
+	assert.NoError(t, c.LoadFile(&cfg, fname))
+	assert.Equal(t, map[string]mapDBConfig{
+		"primary":     {Host: "db1", Port: 5432, Password: "s3cret"},
+		"replica":     {Host: "db2", Port: 7432},
+		"analytics_1": {Host: "db3", Port: 5432},
+	}, cfg.DBs)
+	assert.Equal(t, map[string]*mapDBConfig{
+		"local":  {Host: "localhost", Port: 6379},
+		"shared": {Host: "cache", Port: 5432},
+	}, cfg.Caches)
+
+	// required fields of map values are checked too
+	writeFile(t, fname, "dbs:\n  primary:\n    port: 1\n")
+	err := c.LoadFile(&mapConfig{}, fname)
+	assert.ErrorIs(t, err, configor.ErrRequired)
+	assert.ErrorContains(t, err, "DBs.primary.Host")
+	assert.ErrorContains(t, err, "CONFIGOR_MAP_DBS_PRIMARY_HOST")
+}
+
+func TestMapFieldsBindEnv(t *testing.T) {
+	t.Setenv("CONFIGOR_MAPENV_DBS_MAIN_HOST", "db")
+	t.Setenv("CONFIGOR_MAPENV_DBS_MAIN_PORT", "x")
+
+	var cfg mapConfig
+	c := configor.New()
+	c.EnvPrefix = "CONFIGOR_MAPENV"
+	err := c.BindEnv(&cfg)
+	assert.ErrorContains(t, err, "DBs.main.Port")
+	assert.Equal(t, "db", cfg.DBs["main"].Host)
+}
@@ -0,0 +1,522 @@
+package configor
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Sources are merged the same way whatever their format: every source is
+// decoded into a tree, the trees are deep-merged in order and the result is
+// decoded into dst once. Keys of the trees are the Go field names, so a
+// JSON file and a YAML file address the same field alike.
+//
+// Maps and structs merge key by key, everything else is replaced by later
+// sources. A `null` value removes what earlier sources set. Fields tagged
+// `merge:"append"` concatenate lists instead, fields tagged
+// `merge:"replace"` take maps and structs from the last source as a whole.
+const (
+	mergeAppend  = "append"
+	mergeReplace = "replace"
+)
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	tomlUnmarshalerType = reflect.TypeOf((*toml.Unmarshaler)(nil)).Elem()
+	yamlUnmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// nativeOnly reports whether values of t decode with their UnmarshalJSON
+// or UnmarshalTOML method, yaml calls neither. Such values are merged as a
+// whole and decoded with the method, see setNative.
+func nativeOnly(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	p := reflect.PtrTo(t)
+	if p.Implements(yamlUnmarshalerType) || p.Implements(textUnmarshalerType) {
+		return false
+	}
+	return p.Implements(jsonUnmarshalerType) || p.Implements(tomlUnmarshalerType)
+}
+
+// decodeTree decodes a payload into a generic tree, it fails for
+// unmarshalers that only decode into structs
+func decodeTree(val pair) (map[string]any, error) {
+	tree := map[string]any{}
+	if val.ext == ".json" {
+		// keep large integers intact
+		dec := json.NewDecoder(bytes.NewReader(val.payload))
+		dec.UseNumber()
+		if err := dec.Decode(&tree); err != nil {
+			return nil, err
+		}
+		return tree, nil
+	}
+	if err := val.unmarshaler(val.payload, &tree); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// canonicalize renames the keys of tree to the names of the fields of t
+// they decode into. Keys of embedded structs are moved below the embedded
+// field, keys no field matches are kept as is.
+func canonicalize(tree any, t reflect.Type) any {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t != nil && nativeOnly(t) {
+		t = nil // the keys are the method's business
+	}
+
+	switch v := tree.(type) {
+	case map[string]any:
+		if t == nil || t.Kind() == reflect.Interface {
+			t = nil
+		}
+		out := make(map[string]any, len(v))
+		for k, elem := range v {
+			switch {
+			case t != nil && t.Kind() == reflect.Struct && !isScalar(t):
+				fields := treeField(t, k)
+				if fields == nil {
+					out[k] = canonicalize(elem, nil)
+					continue
+				}
+				m := out
+				for _, f := range fields[:len(fields)-1] {
+					sub, ok := m[f.Name].(map[string]any)
+					if !ok {
+						sub = map[string]any{}
+						m[f.Name] = sub
+					}
+					m = sub
+				}
+				last := fields[len(fields)-1]
+				value := canonicalize(elem, last.Type)
+				if sub, ok := value.(map[string]any); ok {
+					// keys of an embedded struct may be given inline and nested
+					if prev, ok := m[last.Name].(map[string]any); ok {
+						for sk, sv := range sub {
+							prev[sk] = sv
+						}
+						continue
+					}
+				}
+				m[last.Name] = value
+			case t != nil && t.Kind() == reflect.Map:
+				out[k] = canonicalize(elem, t.Elem())
+			default:
+				out[k] = canonicalize(elem, nil)
+			}
+		}
+		return out
+	case []map[string]any: // TOML arrays of tables
+		list := make([]any, len(v))
+		for i, elem := range v {
+			list[i] = elem
+		}
+		return canonicalize(list, t)
+	case []any:
+		var elemType reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			elemType = t.Elem()
+		}
+		out := make([]any, len(v))
+		for i, elem := range v {
+			out[i] = canonicalize(elem, elemType)
+		}
+		return out
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	default:
+		return v
+	}
+}
+
+// treeField finds the field key decodes into, the way any of the formats
+// would: by its `yaml`, `toml` or `json` name or by the field name ignoring
+// case. Fields of embedded structs are found too, the embedded fields on
+// the way come first in the result.
+func treeField(t reflect.Type, key string) []reflect.StructField {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.IsExported() && fieldMatches(f, key) {
+			return []reflect.StructField{f}
+		}
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if !f.Anonymous || !f.IsExported() || !isStruct(ft) {
+			continue
+		}
+		if fields := treeField(ft, key); fields != nil {
+			return append([]reflect.StructField{f}, fields...)
+		}
+	}
+	return nil
+}
+
+func fieldMatches(f reflect.StructField, key string) bool {
+	for _, format := range []string{"yaml", "toml", "json"} {
+		if name, _, _ := strings.Cut(f.Tag.Get(format), ","); name == key {
+			return true
+		}
+	}
+	return strings.EqualFold(f.Name, key)
+}
+
+// mergeTree merges src into dst, both canonical trees of values of t
+func mergeTree(dst, src map[string]any, t reflect.Type) {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	for k, v := range src {
+		if v == nil {
+			delete(dst, k)
+			continue
+		}
+
+		var (
+			elemType reflect.Type
+			strategy string
+		)
+		switch {
+		case t == nil:
+		case t.Kind() == reflect.Struct:
+			if f, ok := t.FieldByName(k); ok {
+				elemType, strategy = f.Type, f.Tag.Get("merge")
+			}
+		case t.Kind() == reflect.Map:
+			elemType = t.Elem()
+		}
+		dst[k] = mergeValue(dst[k], v, elemType, strategy)
+	}
+}
+
+func mergeValue(old, v any, t reflect.Type, strategy string) any {
+	if t != nil && nativeOnly(t) {
+		strategy = mergeReplace
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		merged := make(map[string]any, len(v))
+		if old, ok := old.(map[string]any); ok && strategy != mergeReplace {
+			for k, elem := range old {
+				merged[k] = elem
+			}
+		}
+		// merging into an empty map drops the nulls
+		mergeTree(merged, v, t)
+		return merged
+	case []any:
+		if old, ok := old.([]any); ok && strategy == mergeAppend {
+			return append(old[:len(old):len(old)], v...)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// formatOnly reports whether values of t decode differently in each
+// format, a []byte is base64 in JSON and numbers in an `any` are float64
+// in JSON and int64 in TOML. Such values are decoded like their source
+// format decodes them, see setNative.
+func formatOnly(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return t.Elem().Kind() == reflect.Interface || t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+	}
+	return false
+}
+
+// plainText is a string of a tree that was decrypted or interpolated, it
+// decodes like an env var would, see yamlTree
+type plainText string
+
+// treeString returns the string value of a tree, plainText included
+func treeString(v any) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case plainText:
+		return string(v), true
+	}
+	return "", false
+}
+
+// textLeaf is a value of a tree yaml can not decode, see textOnly,
+// nativeOnly and formatOnly
+type textLeaf struct {
+	path   []string
+	value  any
+	native bool
+}
+
+// yamlTree renames the keys of a canonical tree to the keys YAML decodes
+// into the fields of t, so that the merged tree can be decoded with yaml.
+// Values of textOnly, nativeOnly and formatOnly types are moved to leaves.
+func yamlTree(tree any, t reflect.Type, path []string, leaves *[]textLeaf) (any, bool) {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t != nil && (textOnly(t) || (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && textOnly(t.Elem())) {
+		*leaves = append(*leaves, textLeaf{path, tree, false})
+		return nil, false
+	}
+	if t != nil && nativeOnly(t) {
+		*leaves = append(*leaves, textLeaf{path, plainTree(tree), true})
+		return nil, false
+	}
+	if d, ok := tree.(plainText); ok {
+		if t == nil || t.Kind() == reflect.Interface {
+			return string(d), true
+		}
+		// the plaintext decodes like an env var
+		*leaves = append(*leaves, textLeaf{path, string(d), false})
+		return nil, false
+	}
+	if t != nil && formatOnly(t) {
+		*leaves = append(*leaves, textLeaf{path, plainTree(tree), true})
+		return nil, false
+	}
+
+	switch v := tree.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, elem := range v {
+			elemPath := append(path[:len(path):len(path)], k)
+			switch {
+			case t != nil && t.Kind() == reflect.Struct && !isScalar(t):
+				f, ok := t.FieldByName(k)
+				if !ok || len(f.Index) > 1 {
+					out[k], _ = yamlTree(elem, nil, elemPath, leaves)
+					continue
+				}
+				key, inline, skip := fieldKey(f, "yaml")
+				if skip {
+					continue
+				}
+				value, ok := yamlTree(elem, f.Type, elemPath, leaves)
+				if !ok {
+					continue
+				}
+				if sub, isMap := value.(map[string]any); isMap && inline {
+					for sk, sv := range sub {
+						out[sk] = sv
+					}
+					continue
+				}
+				out[key] = value
+			case t != nil && t.Kind() == reflect.Map:
+				if value, ok := yamlTree(elem, t.Elem(), elemPath, leaves); ok {
+					out[k] = value
+				}
+			default:
+				out[k], _ = yamlTree(elem, nil, elemPath, leaves)
+			}
+		}
+		return out, true
+	case []any:
+		var elemType reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			elemType = t.Elem()
+		}
+		out := make([]any, len(v))
+		for i, elem := range v {
+			// leaves keep their index, they are set once the list is decoded
+			out[i], _ = yamlTree(elem, elemType, append(path[:len(path):len(path)], strconv.Itoa(i)), leaves)
+		}
+		return out, true
+	default:
+		return v, true
+	}
+}
+
+// decodeTrees merges canonical trees and decodes the result into dst,
+// exts are the formats of the trees
+func decodeTrees(dst any, trees []map[string]any, exts []string) error {
+	t := reflect.TypeOf(dst).Elem()
+	merged := map[string]any{}
+	for _, tree := range trees {
+		mergeTree(merged, deepCopy(tree).(map[string]any), t)
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+
+	var leaves []textLeaf
+	doc, _ := yamlTree(merged, t, nil, &leaves)
+	data, err := yaml.Marshal(doc)
+	if err != nil {
+		return errors.Wrap(err, "failed to merge configuration")
+	}
+	if err := yaml.Unmarshal(data, dst); err != nil {
+		return errors.Wrap(err, "failed to load merged configuration")
+	}
+
+	for _, leaf := range leaves {
+		set := func(v reflect.Value) error { return setText(v, leaf.value) }
+		if leaf.native {
+			// the format of the last tree with the value picks the method
+			ext := ""
+			for i := range trees {
+				if _, ok := treeAt(trees[i], leaf.path); ok {
+					ext = exts[i]
+				}
+			}
+			set = func(v reflect.Value) error { return setNative(v, leaf.value, ext) }
+		}
+		var path []string
+		err := setField(reflect.ValueOf(dst).Elem(), leaf.path, set, &path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load %s", strings.Join(leaf.path, "."))
+		}
+	}
+	return nil
+}
+
+// setNative decodes a value of a tree the way the format ext decodes it.
+// nativeOnly values use UnmarshalTOML if they came from TOML or have no
+// UnmarshalJSON, else UnmarshalJSON. formatOnly values from JSON decode
+// with encoding/json, others keep the value of the tree if it fits.
+func setNative(v reflect.Value, value any, ext string) error {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if !nativeOnly(v.Type()) {
+		switch tv := reflect.ValueOf(value); {
+		case ext == ".json":
+			data, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			return json.Unmarshal(data, v.Addr().Interface())
+		case tv.IsValid() && tv.Type().AssignableTo(v.Type()):
+			v.Set(tv)
+			return nil
+		}
+		data, err := yaml.Marshal(value)
+		if err != nil {
+			return err
+		}
+		return yaml.Unmarshal(data, v.Addr().Interface())
+	}
+
+	tu, isTOML := v.Addr().Interface().(toml.Unmarshaler)
+	ju, isJSON := v.Addr().Interface().(json.Unmarshaler)
+	if isTOML && (ext == ".toml" || !isJSON) {
+		return tu.UnmarshalTOML(value)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return ju.UnmarshalJSON(data)
+}
+
+// plainTree copies a tree with its plainText values turned into strings
+func plainTree(tree any) any {
+	switch v := tree.(type) {
+	case plainText:
+		return string(v)
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, elem := range v {
+			out[k] = plainTree(elem)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, elem := range v {
+			out[i] = plainTree(elem)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// deepCopy copies the maps and lists of a tree, merging into a copy keeps
+// the tree intact
+func deepCopy(tree any) any {
+	switch v := tree.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, elem := range v {
+			out[k] = deepCopy(elem)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, elem := range v {
+			out[i] = deepCopy(elem)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// treeAt returns the value at path in a canonical tree
+func treeAt(tree any, path []string) (any, bool) {
+	for _, seg := range path {
+		switch v := tree.(type) {
+		case map[string]any:
+			elem, ok := v[seg]
+			if !ok {
+				return nil, false
+			}
+			tree = elem
+		case []any:
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			tree = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return tree, true
+}
+
+// setText sets a textOnly value, or a list of them, from a tree
+func setText(v reflect.Value, value any) error {
+	list, ok := value.([]any)
+	if !ok || v.Kind() != reflect.Slice {
+		return setValue(v, scalarText(value))
+	}
+	v.Set(reflect.MakeSlice(v.Type(), len(list), len(list)))
+	for i, elem := range list {
+		if err := setValue(v.Index(i), scalarText(elem)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package configor_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cocktail828/go-kits/configor"
+	"github.com/stretchr/testify/assert"
+)
+
+type mergeDB struct {
+	Host string
+	Port int `default:"5432"`
+}
+
+type mergeConfig struct {
+	Name    string
+	Labels  map[string]string
+	Plugins []string `merge:"append"`
+	Hosts   []string
+	DB      mergeDB
+	Limits  map[string]int `merge:"replace"`
+}
+
+func TestMerge(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "base.yaml"), `
+name: base
+labels: {team: core, tier: backend}
+plugins: [auth]
+hosts: [a, b]
+db: {host: db.local, port: 6543}
+limits: {cpu: 2, memory: 512}
+`)
+	writeFile(t, filepath.Join(dir, "local.json"), `{
+  "Labels": {"tier": "frontend", "team": null},
+  "Plugins": ["metrics"],
+  "Hosts": ["c"],
+  "DB": {"Port": null},
+  "Limits": {"cpu": 4}
+}`)
+	writeFile(t, filepath.Join(dir, "extra.toml"), "Plugins = [\"trace\"]\n")
+
+	var cfg mergeConfig
+	c := configor.New()
+	c.EnvPrefix = "CONFIGOR_MERGE"
+	assert.NoError(t, c.LoadFile(&cfg,
+		filepath.Join(dir, "base.yaml"),
+		filepath.Join(dir, "local.json"),
+		filepath.Join(dir, "extra.toml"),
+	))
+	assert.Equal(t, mergeConfig{
+		Name:    "base",
+		Labels:  map[string]string{"tier": "frontend"},
+		Plugins: []string{"auth", "metrics", "trace"},
+		Hosts:   []string{"c"},
+		DB:      mergeDB{Host: "db.local", Port: 5432},
+		Limits:  map[string]int{"cpu": 4},
+	}, cfg)
+}
+
+func TestMergeEmbedded(t *testing.T) {
+	type Common struct {
+		Region string
+		Zone   string
+	}
+	type config struct {
+		Common
+		Name string `json:"app_name" yaml:"name"`
+	}
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.json"), `{"app_name": "a", "Region": "eu", "Zone": "eu-1"}`)
+	writeFile(t, filepath.Join(dir, "b.yaml"), "common:\n  zone: eu-2\n")
+
+	var cfg config
+	c := configor.New()
+	c.EnvPrefix = "CONFIGOR_MERGE"
+	assert.NoError(t, c.LoadFile(&cfg, filepath.Join(dir, "a.json"), filepath.Join(dir, "b.yaml")))
+	assert.Equal(t, config{Common: Common{Region: "eu", Zone: "eu-2"}, Name: "a"}, cfg)
+}
+
+// mergeLevel decodes from JSON only, by name or by number
+type mergeLevel int
+
+func (l *mergeLevel) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return json.Unmarshal(data, (*int)(l))
+	}
+	switch strings.ToLower(name) {
+	case "debug":
+		*l = 0
+	case "info":
+		*l = 1
+	default:
+		return fmt.Errorf("unknown level %q", name)
+	}
+	return nil
+}
+
+// mergePeer decodes from TOML only, from "host:port"
+type mergePeer struct {
+	Host string
+	Port string
+}
+
+func (p *mergePeer) UnmarshalTOML(v any) error {
+	s, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("peer %v is not a string", v)
+	}
+	p.Host, p.Port, _ = strings.Cut(s, ":")
+	return nil
+}
+
+func TestMergeNativeUnmarshalers(t *testing.T) {
+	type config struct {
+		Level  mergeLevel
+		Levels []mergeLevel
+		ByName map[string]*mergeLevel
+		Peer   mergePeer
+	}
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "config.json"), `{"level": "debug", "levels": ["info", 0], "byName": {"a": "info"}}`)
+	writeFile(t, filepath.Join(dir, "config.toml"), `peer = "db:5432"`)
+	writeFile(t, filepath.Join(dir, "override.yaml"), "level: info\n")
+
+	var cfg config
+	assert.NoError(t, configor.LoadFile(&cfg, filepath.Join(dir, "config.json"), filepath.Join(dir, "config.toml")))
+	assert.Equal(t, mergeLevel(0), cfg.Level)
+	assert.Equal(t, []mergeLevel{1, 0}, cfg.Levels)
+	if assert.NotNil(t, cfg.ByName["a"]) {
+		assert.Equal(t, mergeLevel(1), *cfg.ByName["a"])
+	}
+	assert.Equal(t, mergePeer{"db", "5432"}, cfg.Peer)
+
+	// a later YAML layer replaces the value, UnmarshalJSON still decodes it
+	cfg = config{}
+	assert.NoError(t, configor.LoadFile(&cfg, filepath.Join(dir, "config.json"), filepath.Join(dir, "override.yaml")))
+	assert.Equal(t, mergeLevel(1), cfg.Level)
+
+	writeFile(t, filepath.Join(dir, "bad.json"), `{"level": "loud"}`)
+	assert.ErrorContains(t, configor.LoadFile(&config{}, filepath.Join(dir, "bad.json")), `unknown level "loud"`)
+}
+
+// mergeSize decodes from TOML and from JSON, and tells which one ran
+type mergeSize struct {
+	N    int64
+	From string
+}
+
+func (s *mergeSize) UnmarshalTOML(v any) error {
+	n, ok := v.(int64)
+	if !ok {
+		return fmt.Errorf("size %v is not an integer", v)
+	}
+	*s = mergeSize{n, "toml"}
+	return nil
+}
+
+func (s *mergeSize) UnmarshalJSON(data []byte) error {
+	s.From = "json"
+	return json.Unmarshal(data, &s.N)
+}
+
+func TestMergeNativeFormat(t *testing.T) {
+	type config struct {
+		X mergeSize
+	}
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "config.toml"), "X = 5\n")
+	writeFile(t, filepath.Join(dir, "config.json"), `{"X": 6}`)
+
+	var cfg config
+	assert.NoError(t, configor.LoadFile(&cfg, filepath.Join(dir, "config.toml")))
+	assert.Equal(t, mergeSize{5, "toml"}, cfg.X)
+
+	cfg = config{}
+	assert.NoError(t, configor.LoadFile(&cfg, filepath.Join(dir, "config.toml"), filepath.Join(dir, "config.json")))
+	assert.Equal(t, mergeSize{6, "json"}, cfg.X)
+}
+
+func TestMergeFormatTypes(t *testing.T) {
+	type config struct {
+		Data   []byte
+		Extra  any
+		Labels map[string]any
+		Items  []any
+	}
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "config.json"), `{"Data": "aGVsbG8=", "Extra": {"n": 1, "s": "x"}, "Labels": {"a": 2}, "Items": [3, "y"]}`)
+	writeFile(t, filepath.Join(dir, "config.toml"), "Extra = 1\nItems = [4]\n")
+
+	// decoded the way encoding/json decodes them
+	var cfg config
+	assert.NoError(t, configor.LoadFile(&cfg, filepath.Join(dir, "config.json")))
+	assert.Equal(t, config{
+		Data:   []byte("hello"),
+		Extra:  map[string]any{"n": float64(1), "s": "x"},
+		Labels: map[string]any{"a": float64(2)},
+		Items:  []any{float64(3), "y"},
+	}, cfg)
+
+	// and the way toml does
+	cfg = config{}
+	assert.NoError(t, configor.LoadFile(&cfg, filepath.Join(dir, "config.toml")))
+	assert.Equal(t, config{Extra: int64(1), Items: []any{int64(4)}}, cfg)
+}
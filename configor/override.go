@@ -0,0 +1,182 @@
+package configor
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Set assigns value to the field of dst at the dotted path, like
+// `DB.Port` or `Contacts.0.Email`. Field names match case-insensitively,
+// fields of `anonymous:"true"` embedded structs are addressed without the
+// embedded name like their env names are, other embedded structs by it,
+// slices grow by one when the index equals their length and map keys are
+// used as is. The value is decoded like an env var.
+func (c *Configor) Set(dst any, path, value string) error {
+	_, err := setPath(dst, path, value)
+	return err
+}
+
+// setPath is Set that also returns the path spelled with the field names
+func setPath(dst any, path, value string) ([]string, error) {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return nil, errors.Errorf("Config %v should be a pointer", dst)
+	}
+	var canonical []string
+	err := setField(v.Elem(), strings.Split(path, "."), func(v reflect.Value) error {
+		return setValue(v, value)
+	}, &canonical)
+	return canonical, err
+}
+
+// setField walks v along segs and calls set with the field found
+func setField(v reflect.Value, segs []string, set func(reflect.Value) error, canonical *[]string) error {
+	if len(segs) == 0 {
+		return set(v)
+	}
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+
+	seg := segs[0]
+	switch {
+	case v.Kind() == reflect.Struct && !isScalar(v.Type()):
+		field, names := findField(v, seg)
+		if !field.IsValid() {
+			return errors.Errorf("unknown field %q", seg)
+		}
+		*canonical = append(*canonical, names...)
+		return setField(field, segs[1:], set, canonical)
+	case v.Kind() == reflect.Slice || v.Kind() == reflect.Array:
+		idx, err := strconv.Atoi(seg)
+		if err != nil || idx < 0 {
+			return errors.Errorf("invalid index %q", seg)
+		}
+		if v.Kind() == reflect.Slice && idx == v.Len() {
+			// the list only grows once the new element is set
+			elem := reflect.New(v.Type().Elem()).Elem()
+			*canonical = append(*canonical, seg)
+			if err := setField(elem, segs[1:], set, canonical); err != nil {
+				return err
+			}
+			v.Set(reflect.Append(v, elem))
+			return nil
+		}
+		if idx >= v.Len() {
+			return errors.Errorf("index %d out of range [0:%d]", idx, v.Len())
+		}
+		*canonical = append(*canonical, seg)
+		return setField(v.Index(idx), segs[1:], set, canonical)
+	case v.Kind() == reflect.Map:
+		key := reflect.New(v.Type().Key()).Elem()
+		if err := setValue(key, seg); err != nil {
+			return errors.Wrapf(err, "invalid key %q", seg)
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		// map values are not addressable, update a copy
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if old := v.MapIndex(key); old.IsValid() {
+			elem.Set(old)
+		}
+		*canonical = append(*canonical, seg)
+		if err := setField(elem, segs[1:], set, canonical); err != nil {
+			return err
+		}
+		v.SetMapIndex(key, elem)
+		return nil
+	default:
+		return errors.Errorf("unknown field %q", seg)
+	}
+}
+
+// findField looks up an exported field by name, then inside structs
+// embedded with `anonymous:"true"`, the ones whose fields env names and
+// flags address without the embedded name. Embedded pointers on the way
+// are allocated.
+func findField(v reflect.Value, name string) (reflect.Value, []string) {
+	index, names := fieldIndex(v.Type(), name)
+	if index == nil {
+		return reflect.Value{}, nil
+	}
+	for _, i := range index {
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v, names
+}
+
+func fieldIndex(t reflect.Type, name string) ([]int, []string) {
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); f.IsExported() && strings.EqualFold(f.Name, name) {
+			return []int{i}, []string{f.Name}
+		}
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if !f.Anonymous || !f.IsExported() || ft.Kind() != reflect.Struct || f.Tag.Get("anonymous") != "true" {
+			continue
+		}
+		if index, names := fieldIndex(ft, name); index != nil {
+			return append([]int{i}, index...), append([]string{f.Name}, names...)
+		}
+	}
+	return nil, nil
+}
+
+// processOverrides applies c.Overrides in the order of their paths,
+// indices in numeric order so that a list grows one element at a time
+func (c *Configor) processOverrides(dst any, state *loadState) {
+	paths := make([]string, 0, len(c.Overrides))
+	for k := range c.Overrides {
+		paths = append(paths, k)
+	}
+	sort.Slice(paths, func(i, j int) bool { return lessPath(paths[i], paths[j]) })
+
+	for _, path := range paths {
+		value, ok := c.decrypt(state, strings.Split(path, "."), c.Overrides[path])
+		if !ok {
+			continue
+		}
+		canonical, err := setPath(dst, path, value)
+		if err != nil {
+			state.errs.add(strings.Split(path, "."), []string{"override"}, err)
+			continue
+		}
+		state.record(canonical, "override")
+	}
+}
+
+// lessPath orders dotted paths segment by segment, numbers by their value
+func lessPath(a, b string) bool {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+		an, aerr := strconv.Atoi(as[i])
+		bn, berr := strconv.Atoi(bs[i])
+		if aerr == nil && berr == nil {
+			return an < bn
+		}
+		return as[i] < bs[i]
+	}
+	return len(as) < len(bs)
+}
@@ -0,0 +1,104 @@
+package configor_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/cocktail828/go-kits/configor"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+type overrideConfig struct {
+	Name string `required:"true"`
+	DB   struct {
+		Port int `default:"3306"`
+		SSL  *bool
+	}
+	Hosts    []string
+	Contacts []struct {
+		Email string `required:"true"`
+	}
+	Labels    map[string]string
+	Anonymous `anonymous:"true"`
+}
+
+func TestSet(t *testing.T) {
+	var cfg overrideConfig
+	c := &configor.Configor{}
+	assert.NoError(t, c.Set(&cfg, "db.port", "3307"))
+	assert.NoError(t, c.Set(&cfg, "DB.SSL", "true"))
+	assert.NoError(t, c.Set(&cfg, "Hosts", "[a, b]"))
+	assert.NoError(t, c.Set(&cfg, "Contacts.0.Email", "x@y"))
+	assert.NoError(t, c.Set(&cfg, "Labels.team", "infra"))
+	assert.NoError(t, c.Set(&cfg, "Description", "flattened"))
+
+	assert.Equal(t, 3307, cfg.DB.Port)
+	assert.True(t, *cfg.DB.SSL)
+	assert.Equal(t, []string{"a", "b"}, cfg.Hosts)
+	assert.Equal(t, "x@y", cfg.Contacts[0].Email)
+	assert.Equal(t, map[string]string{"team": "infra"}, cfg.Labels)
+	assert.Equal(t, "flattened", cfg.Anonymous.Description)
+
+	assert.ErrorContains(t, c.Set(&cfg, "DB.Pasword", "x"), `unknown field "Pasword"`)
+	assert.ErrorContains(t, c.Set(&cfg, "Contacts.2.Email", "x"), "out of range")
+	assert.Error(t, c.Set(&cfg, "DB.Port", "abc"))
+}
+
+func TestWithOverrides(t *testing.T) {
+	c := configor.New(configor.WithOverrides(map[string]string{
+		"Name":             "override",
+		"DB.Port":          "3307",
+		"Contacts.0.Email": "x@y",
+	}))
+	c.EnvPrefix = "CONFIGOR_OVERRIDE"
+	c.Unmarshaler = yaml.Unmarshal
+
+	var cfg overrideConfig
+	assert.NoError(t, c.Load(&cfg, []byte("name: file\ndb:\n  port: 1")))
+	assert.Equal(t, "override", cfg.Name)
+	assert.Equal(t, 3307, cfg.DB.Port)
+	assert.Equal(t, "x@y", cfg.Contacts[0].Email)
+
+	// indices apply in numeric order, Contacts.10 after Contacts.2
+	overrides := map[string]string{}
+	for i := 0; i <= 10; i++ {
+		overrides[fmt.Sprintf("Contacts.%d.Email", i)] = fmt.Sprintf("%d@y", i)
+	}
+	cfg = overrideConfig{Name: "app"}
+	assert.NoError(t, configor.New(configor.WithOverrides(overrides)).Load(&cfg))
+	if assert.Len(t, cfg.Contacts, 11) {
+		assert.Equal(t, "10@y", cfg.Contacts[10].Email)
+	}
+
+	c = configor.New(configor.WithOverrides(map[string]string{"DB.Pasword": "x"}))
+	c.EnvPrefix = "CONFIGOR_OVERRIDE"
+	err := c.Load(&overrideConfig{Name: "app"})
+	var ferr *configor.FieldError
+	if assert.True(t, errors.As(err, &ferr)) {
+		assert.Equal(t, "DB.Pasword", ferr.Path)
+		assert.Equal(t, []string{"override"}, ferr.Sources)
+	}
+}
+
+func TestSetEmbedded(t *testing.T) {
+	type config struct {
+		Anonymous
+		Ports []int
+	}
+
+	// fields of embedded structs without `anonymous:"true"` keep the
+	// embedded name, like their env names
+	var cfg config
+	c := &configor.Configor{}
+	assert.NoError(t, c.Set(&cfg, "Anonymous.Description", "nested"))
+	assert.Equal(t, "nested", cfg.Description)
+	assert.ErrorContains(t, c.Set(&cfg, "Description", "flat"), `unknown field "Description"`)
+
+	// a list only grows by elements that are set
+	assert.Error(t, c.Set(&cfg, "Ports.0", "abc"))
+	assert.Empty(t, cfg.Ports)
+	assert.NoError(t, c.Set(&cfg, "Ports.0", "80"))
+	assert.Equal(t, []int{80}, cfg.Ports)
+}
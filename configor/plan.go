@@ -0,0 +1,197 @@
+package configor
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// structPlan is what a load needs to know about the fields of a struct
+// type. It is built once per type and shared by every load, see planOf.
+type structPlan struct {
+	fields []*fieldPlan
+}
+
+// fieldPlan describes an exported field of a struct
+type fieldPlan struct {
+	reflect.StructField
+
+	// defaultTag is the `default` tag, defaultValue its decoded value if
+	// the field is of a basic kind, which is safe to copy into every load
+	defaultTag   string
+	defaultValue reflect.Value
+	required     bool
+
+	// kind is the kind of the field type with pointers removed, scalar
+	// tells if that type decodes from text, see isScalar
+	kind   reflect.Kind
+	scalar bool
+	// entries tells if the field is a list or map read from one env var
+	// per entry, see lookupEnvEntries
+	entries bool
+
+	// names caches the fieldNames by EnvPrefix and prefixes. The prefixes
+	// hold map keys and list indices, so at most maxCachedNames nodes are
+	// kept and the names below any further prefixes are worked out every
+	// time.
+	namesMu     sync.RWMutex
+	names       map[namesKey]*namesNode
+	cachedNames atomic.Int32
+}
+
+// maxCachedNames bounds the namesNodes cached per field
+const maxCachedNames = 64
+
+// namesKey is what the names of a field depend on besides the prefixes
+type namesKey struct {
+	tagsOnly  bool
+	envPrefix string
+}
+
+// namesNode holds the names of a field below a prefix and, by the next
+// prefix, the nodes below it. Lookups walk the prefixes without
+// allocating.
+type namesNode struct {
+	names    atomic.Pointer[fieldNames]
+	mu       sync.RWMutex
+	children map[string]*namesNode
+}
+
+// fieldNames are the env and flag names of a field below some prefixes
+type fieldNames struct {
+	env  []string
+	flag string
+}
+
+var plans sync.Map // reflect.Type -> *structPlan
+
+// cachePlans is false in benchmarks of the uncached path only, plans and
+// names are then worked out for every struct and field a load visits
+var cachePlans = true
+
+// planOf returns the plan of the struct type t
+func planOf(t reflect.Type) *structPlan {
+	if !cachePlans {
+		return newPlan(t, false)
+	}
+	if p, ok := plans.Load(t); ok {
+		return p.(*structPlan)
+	}
+	actual, _ := plans.LoadOrStore(t, newPlan(t, true))
+	return actual.(*structPlan)
+}
+
+// newPlan builds the plan of the struct type t, decodeDefaults tells if
+// the `default` tags of basic kinds are decoded once
+func newPlan(t reflect.Type, decodeDefaults bool) *structPlan {
+	p := &structPlan{}
+	for i := 0; i < t.NumField(); i++ {
+		fieldStruct := t.Field(i)
+		if !fieldStruct.IsExported() {
+			continue
+		}
+		ft := fieldStruct.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		fp := &fieldPlan{
+			StructField: fieldStruct,
+			defaultTag:  fieldStruct.Tag.Get("default"),
+			required:    fieldStruct.Tag.Get("required") == "true",
+			kind:        ft.Kind(),
+			scalar:      isScalar(ft),
+		}
+		fp.entries = !fp.scalar && (fp.kind == reflect.Slice || fp.kind == reflect.Map) && !isStruct(ft.Elem())
+		if decodeDefaults && fp.defaultTag != "" && isBasic(fieldStruct.Type) {
+			v := reflect.New(fieldStruct.Type).Elem()
+			if setDefault(v, fp.defaultTag) == nil {
+				fp.defaultValue = v
+			}
+		}
+		p.fields = append(p.fields, fp)
+	}
+	return p
+}
+
+// isBasic reports whether t is a bool, number or string, values of it
+// hold no references
+func isBasic(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return true
+	}
+	return false
+}
+
+// names returns the env names and the flag name of the field below
+// prefixes, see envNames and flagName
+func (c *Configor) names(fp *fieldPlan, prefixes []string) *fieldNames {
+	if !cachePlans {
+		return c.newNames(fp, prefixes)
+	}
+
+	key := namesKey{c.tagsOnly, c.EnvPrefix}
+	fp.namesMu.RLock()
+	node := fp.names[key]
+	fp.namesMu.RUnlock()
+	if node == nil {
+		fp.namesMu.Lock()
+		if node = fp.names[key]; node == nil && fp.cachedNames.Load() < maxCachedNames {
+			if fp.names == nil {
+				fp.names = map[namesKey]*namesNode{}
+			}
+			node = &namesNode{}
+			fp.names[key] = node
+			fp.cachedNames.Add(1)
+		}
+		fp.namesMu.Unlock()
+	}
+
+	for _, prefix := range prefixes {
+		if node == nil {
+			break
+		}
+		node = node.child(fp, prefix)
+	}
+	if node == nil {
+		return c.newNames(fp, prefixes)
+	}
+	if names := node.names.Load(); names != nil {
+		return names
+	}
+	names := c.newNames(fp, prefixes)
+	node.names.Store(names)
+	return names
+}
+
+func (c *Configor) newNames(fp *fieldPlan, prefixes []string) *fieldNames {
+	return &fieldNames{
+		env:  c.envNames(prefixes, &fp.StructField),
+		flag: c.flagName(prefixes, &fp.StructField),
+	}
+}
+
+// child returns the node below prefix, nil once fp has maxCachedNames
+func (n *namesNode) child(fp *fieldPlan, prefix string) *namesNode {
+	n.mu.RLock()
+	child := n.children[prefix]
+	n.mu.RUnlock()
+	if child != nil {
+		return child
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if child = n.children[prefix]; child == nil && fp.cachedNames.Load() < maxCachedNames {
+		if n.children == nil {
+			n.children = map[string]*namesNode{}
+		}
+		child = &namesNode{}
+		n.children[prefix] = child
+		fp.cachedNames.Add(1)
+	}
+	return child
+}
@@ -0,0 +1,120 @@
+package configor
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type planDB struct {
+	Host     string        `default:"localhost"`
+	Port     int           `default:"5432"`
+	User     string        `default:"root"`
+	Password string        `required:"true"`
+	Timeout  time.Duration `default:"5s"`
+	Pool     struct {
+		Min int `default:"1"`
+		Max int `default:"10"`
+	}
+}
+
+type planConfig struct {
+	Name     string `default:"app"`
+	Debug    bool
+	Hosts    []string
+	Primary  planDB
+	Replicas []planDB
+	DBs      map[string]planDB
+}
+
+var planPayload = []byte(`
+name = "bench"
+hosts = ["a", "b", "c"]
+
+[primary]
+password = "s3cret"
+
+[[replicas]]
+host = "r1"
+password = "s3cret"
+
+[[replicas]]
+host = "r2"
+password = "s3cret"
+
+[dbs.acme]
+password = "s3cret"
+
+[dbs.globex]
+password = "s3cret"
+`)
+
+func TestNamesCache(t *testing.T) {
+	c := New()
+	c.EnvPrefix = "APP"
+	fp := planOf(reflect.TypeOf(planDB{})).fields[0]
+
+	prefixes := []string{"DBs", "primary"}
+	names := c.names(fp, prefixes)
+	if want := []string{"APP_DBs_primary_Host", "APP_DBS_PRIMARY_HOST"}; !reflect.DeepEqual(names.env, want) {
+		t.Fatalf("names.env = %v, want %v", names.env, want)
+	}
+	if allocs := testing.AllocsPerRun(100, func() { c.names(fp, prefixes) }); allocs != 0 {
+		t.Fatalf("a cached lookup allocates %v times", allocs)
+	}
+
+	// map keys and list indices do not grow the cache without bound
+	for i := 0; i < 10*maxCachedNames; i++ {
+		names := c.names(fp, []string{"DBs", fmt.Sprint("db", i)})
+		if want := fmt.Sprint("APP_DBs_db", i, "_Host"); names.env[0] != want {
+			t.Fatalf("names.env = %v, want %s first", names.env, want)
+		}
+	}
+	if cached := fp.cachedNames.Load(); cached > maxCachedNames {
+		t.Fatalf("%d names cached, want at most %d", cached, maxCachedNames)
+	}
+}
+
+// benchmarkPlans runs load with plans and names cached and without, the
+// latter walks the fields of every struct and works out their names and
+// defaults on every load, as processDefaults and processTags did before
+// plans
+func benchmarkPlans(b *testing.B, load func() error) {
+	for _, cached := range []bool{true, false} {
+		name := "cached"
+		if !cached {
+			name = "uncached"
+		}
+		b.Run(name, func(b *testing.B) {
+			cachePlans = cached
+			defer func() { cachePlans = true }()
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := load(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkPlansLoad(b *testing.B) {
+	b.Setenv("CONFIGOR_PLAN_DEBUG", "true")
+	c := New()
+	c.EnvPrefix = "CONFIGOR_PLAN"
+	benchmarkPlans(b, func() error {
+		var cfg planConfig
+		return c.Load(&cfg, planPayload)
+	})
+}
+
+func BenchmarkPlansBindEnv(b *testing.B) {
+	b.Setenv("CONFIGOR_PLAN_PRIMARY_PASSWORD", "s3cret")
+	c := New()
+	c.EnvPrefix = "CONFIGOR_PLAN"
+	benchmarkPlans(b, func() error {
+		var cfg planConfig
+		return c.BindEnv(&cfg)
+	})
+}
@@ -0,0 +1,143 @@
+package configor
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// includeKeys are the top level keys that pull other files into a file
+var includeKeys = []string{"include", "$import"}
+
+// profileFile returns the overlay of fname for env, `config.yaml` becomes `config.prod.yaml`
+func profileFile(fname, env string) string {
+	ext := filepath.Ext(fname)
+	return strings.TrimSuffix(fname, ext) + "." + env + ext
+}
+
+// readLayers reads fname with its includes and its profile overlay, from
+// fsys or from the OS if fsys is nil
+func (c *Configor) readLayers(fsys fs.FS, fname, env string) ([]pair, error) {
+	pairs, err := c.readIncludes(fsys, fname, nil)
+	if err != nil {
+		return nil, err
+	}
+	if env == "" {
+		return pairs, nil
+	}
+
+	overlay := profileFile(fname, env)
+	if _, err := statFS(fsys, overlay); err != nil {
+		if os.IsNotExist(err) {
+			return pairs, nil // overlays are optional
+		}
+		return nil, err
+	}
+	layers, err := c.readIncludes(fsys, overlay, nil)
+	if err != nil {
+		return nil, err
+	}
+	return append(pairs, layers...), nil
+}
+
+// readIncludes reads the files fname includes, depth first, followed by
+// fname itself. stack holds the files being included to catch cycles.
+func (c *Configor) readIncludes(fsys fs.FS, fname string, stack []string) ([]pair, error) {
+	abs := path.Clean(fname)
+	if fsys == nil {
+		var err error
+		if abs, err = filepath.Abs(fname); err != nil {
+			return nil, err
+		}
+	}
+	for i, f := range stack {
+		if f == abs {
+			return nil, errors.Errorf("include cycle: %s", strings.Join(append(stack[i:], abs), " -> "))
+		}
+	}
+	stack = append(stack[:len(stack):len(stack)], abs)
+
+	data, err := readFS(fsys, fname)
+	if err != nil {
+		if len(stack) > 1 {
+			return nil, errors.Wrapf(err, "missing include of %s", stack[len(stack)-2])
+		}
+		return nil, err
+	}
+
+	unmarshaler := c.unmarshalerOf(fname)
+	pairs := []pair{}
+	includes, err := c.includesOf(data, unmarshaler)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load %s", fname)
+	}
+	for _, inc := range includes {
+		switch {
+		case fsys != nil:
+			inc = path.Join(path.Dir(fname), inc)
+		case !filepath.IsAbs(inc):
+			inc = filepath.Join(filepath.Dir(fname), inc)
+		}
+		layers, err := c.readIncludes(fsys, inc, stack)
+		if err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, layers...)
+	}
+	if fsys != nil {
+		return append(pairs, pair{"file " + fname, data, unmarshaler, path.Ext(fname), ""}), nil
+	}
+	return append(pairs, pair{"file " + fname, data, unmarshaler, path.Ext(fname), fname}), nil
+}
+
+func readFS(fsys fs.FS, fname string) ([]byte, error) {
+	if fsys == nil {
+		return os.ReadFile(fname)
+	}
+	return fs.ReadFile(fsys, fname)
+}
+
+func statFS(fsys fs.FS, fname string) (fs.FileInfo, error) {
+	if fsys == nil {
+		return os.Stat(fname)
+	}
+	return fs.Stat(fsys, fname)
+}
+
+// includesOf returns the files listed under the include keys of payload,
+// interpolated if Interpolate is set. Payloads that do not decode into a
+// map include nothing, the actual load reports their errors.
+func (c *Configor) includesOf(payload []byte, unmarshaler func([]byte, any) error) ([]string, error) {
+	var doc map[string]any
+	if unmarshaler == nil || unmarshaler(payload, &doc) != nil {
+		return nil, nil
+	}
+
+	var files []string
+	for _, key := range includeKeys {
+		switch v := doc[key].(type) {
+		case string:
+			files = append(files, v)
+		case []any:
+			for _, f := range v {
+				if s, ok := f.(string); ok {
+					files = append(files, s)
+				}
+			}
+		}
+	}
+	if c.Interpolate {
+		for i, f := range files {
+			value, err := c.interpolate(f)
+			if err != nil {
+				return nil, errors.Wrap(err, "include")
+			}
+			files[i] = value
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,62 @@
+package configor_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/cocktail828/go-kits/configor"
+	"github.com/stretchr/testify/assert"
+)
+
+type profileConfig struct {
+	Name  string
+	Port  int
+	Debug bool
+	Hosts []string
+}
+
+func TestProfile(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "config.yaml"), "name: app\nport: 80\ndebug: true\n")
+	writeFile(t, filepath.Join(dir, "config.prod.yaml"), "port: 443\ndebug: false\n")
+
+	var cfg profileConfig
+	c := configor.New(configor.WithEnvironment("prod"))
+	c.EnvPrefix = "CONFIGOR_PROFILE"
+	assert.NoError(t, c.LoadFile(&cfg, filepath.Join(dir, "config.yaml")))
+	assert.Equal(t, profileConfig{Name: "app", Port: 443}, cfg)
+
+	// a missing overlay is fine
+	cfg = profileConfig{}
+	c.Environment = "dev"
+	assert.NoError(t, c.LoadFile(&cfg, filepath.Join(dir, "config.yaml")))
+	assert.Equal(t, profileConfig{Name: "app", Port: 80, Debug: true}, cfg)
+}
+
+func TestInclude(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "base.yaml"), "name: base\nport: 80\nhosts: [a]\n")
+	writeFile(t, filepath.Join(dir, "conf.d", "hosts.json"), `{"hosts": ["b", "c"]}`)
+	writeFile(t, filepath.Join(dir, "config.yaml"), "include:\n  - base.yaml\n  - conf.d/hosts.json\nport: 8080\n")
+	writeFile(t, filepath.Join(dir, "config.prod.yaml"), "$import: prod.yaml\n")
+	writeFile(t, filepath.Join(dir, "prod.yaml"), "debug: true\n")
+
+	var cfg profileConfig
+	c := &configor.Configor{EnvPrefix: "CONFIGOR_PROFILE", Environment: "prod"}
+	assert.NoError(t, c.LoadFile(&cfg, filepath.Join(dir, "config.yaml")))
+	assert.Equal(t, profileConfig{Name: "base", Port: 8080, Debug: true, Hosts: []string{"b", "c"}}, cfg)
+}
+
+func TestIncludeErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "missing.yaml"), "include: nope.yaml\n")
+	writeFile(t, filepath.Join(dir, "a.yaml"), "include: b.yaml\n")
+	writeFile(t, filepath.Join(dir, "b.yaml"), "include: [a.yaml]\n")
+
+	c := &configor.Configor{EnvPrefix: "CONFIGOR_PROFILE"}
+	err := c.LoadFile(&profileConfig{}, filepath.Join(dir, "missing.yaml"))
+	assert.ErrorContains(t, err, "missing include of "+filepath.Join(dir, "missing.yaml"))
+
+	err = c.LoadFile(&profileConfig{}, filepath.Join(dir, "a.yaml"))
+	assert.ErrorContains(t, err, "include cycle: "+filepath.Join(dir, "a.yaml")+" -> "+filepath.Join(dir, "b.yaml")+" -> "+filepath.Join(dir, "a.yaml"))
+}
@@ -0,0 +1,165 @@
+package configor
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Redacted replaces the value of `secret:"true"` fields in dumps, diffs and logs
+const Redacted = "******"
+
+func isSecret(f reflect.StructField) bool {
+	return f.Tag.Get("secret") == "true"
+}
+
+// redact returns the value to show for a leaf, blank secrets stay blank so
+// that a missing secret is still visible
+func redact(v reflect.Value, secret bool) reflect.Value {
+	if !secret || !v.IsValid() || v.IsZero() {
+		return v
+	}
+	return reflect.ValueOf(Redacted)
+}
+
+// Redact returns a deep copy of cfg with every `secret:"true"` field masked,
+// it is safe to marshal or print. Secret strings become Redacted, other
+// secret values are zeroed.
+func Redact(cfg any) any {
+	v := reflect.ValueOf(cfg)
+	if !v.IsValid() {
+		return cfg
+	}
+	return redactCopy(v, false).Interface()
+}
+
+func redactCopy(v reflect.Value, secret bool) reflect.Value {
+	if secret && v.Kind() != reflect.Ptr && isScalar(v.Type()) {
+		// a leaf like a URL, its unexported parts hold secrets as well
+		if v.Kind() == reflect.String && v.Len() > 0 {
+			return reflect.ValueOf(Redacted).Convert(v.Type())
+		}
+		return reflect.Zero(v.Type())
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		nv := reflect.New(v.Type().Elem())
+		nv.Elem().Set(redactCopy(v.Elem(), secret))
+		return nv
+	case reflect.Struct:
+		nv := reflect.New(v.Type()).Elem()
+		nv.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Type().Field(i); f.IsExported() {
+				nv.Field(i).Set(redactCopy(v.Field(i), secret || isSecret(f)))
+			}
+		}
+		return nv
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		nv := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			nv.Index(i).Set(redactCopy(v.Index(i), secret))
+		}
+		return nv
+	case reflect.Array:
+		nv := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			nv.Index(i).Set(redactCopy(v.Index(i), secret))
+		}
+		return nv
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		nv := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			nv.SetMapIndex(iter.Key(), redactCopy(iter.Value(), secret))
+		}
+		return nv
+	case reflect.String:
+		if secret && v.Len() > 0 {
+			return reflect.ValueOf(Redacted).Convert(v.Type())
+		}
+		return v
+	default:
+		if secret && v.IsValid() {
+			return reflect.Zero(v.Type())
+		}
+		return v
+	}
+}
+
+// Fields flattens cfg into alternating dotted path and value pairs with
+// secrets masked, ready for the structured methods of pkg/logger:
+//
+//	l.Infow("config loaded", configor.Fields(cfg)...)
+func Fields(cfg any) []any {
+	var fields []any
+	walkLeaves(reflect.ValueOf(cfg), nil, false, func(path []string, v reflect.Value, secret bool) {
+		if !v.IsValid() || !v.CanInterface() {
+			return
+		}
+		fields = append(fields, strings.Join(path, "."), redact(v, secret).Interface())
+	})
+	return fields
+}
+
+// Change is a leaf that differs between two configurations
+type Change struct {
+	Path string
+	Old  any
+	New  any
+}
+
+// Diff lists the leaves that differ between old and new in field order,
+// both are expected to have the same type. Secrets are masked.
+func Diff(old, new any) []Change {
+	type leaf struct {
+		value  any
+		secret bool
+	}
+	collect := func(cfg any) ([]string, map[string]leaf) {
+		paths, leaves := []string{}, map[string]leaf{}
+		walkLeaves(reflect.ValueOf(cfg), nil, false, func(path []string, v reflect.Value, secret bool) {
+			if !v.IsValid() || !v.CanInterface() {
+				return
+			}
+			key := strings.Join(path, ".")
+			paths = append(paths, key)
+			leaves[key] = leaf{v.Interface(), secret}
+		})
+		return paths, leaves
+	}
+
+	oldPaths, oldLeaves := collect(old)
+	newPaths, newLeaves := collect(new)
+	show := func(l leaf, ok bool) any {
+		if !ok || l.value == nil {
+			return nil
+		}
+		return redact(reflect.ValueOf(l.value), l.secret).Interface()
+	}
+
+	changes := []Change{}
+	seen := map[string]bool{}
+	for _, key := range append(oldPaths, newPaths...) {
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		o, ook := oldLeaves[key]
+		n, nok := newLeaves[key]
+		if ook && nok && reflect.DeepEqual(o.value, n.value) {
+			continue
+		}
+		changes = append(changes, Change{Path: key, Old: show(o, ook), New: show(n, nok)})
+	}
+	return changes
+}
@@ -0,0 +1,121 @@
+package configor_test
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/cocktail828/go-kits/configor"
+	"github.com/cocktail828/go-kits/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/exp/slog"
+)
+
+type secretConfig struct {
+	Name string
+	DB   struct {
+		User     string
+		Password string `secret:"true"`
+	}
+	Tokens []string `secret:"true"`
+}
+
+func newSecretConfig() *secretConfig {
+	cfg := &secretConfig{Name: "app", Tokens: []string{"t1", "t2"}}
+	cfg.DB.User = "root"
+	cfg.DB.Password = "p@ss"
+	return cfg
+}
+
+func TestRedact(t *testing.T) {
+	cfg := newSecretConfig()
+	redacted := configor.Redact(cfg).(*secretConfig)
+	assert.Equal(t, "root", redacted.DB.User)
+	assert.Equal(t, configor.Redacted, redacted.DB.Password)
+	assert.Equal(t, []string{configor.Redacted, configor.Redacted}, redacted.Tokens)
+	assert.Equal(t, newSecretConfig(), cfg)
+}
+
+func TestFields(t *testing.T) {
+	var buf bytes.Buffer
+	l := logger.NewLoggerWithSlog(slog.New(slog.NewJSONHandler(&buf, nil)))
+	l.Infow("config loaded", configor.Fields(newSecretConfig())...)
+
+	out := buf.String()
+	assert.Contains(t, out, `"DB.User":"root"`)
+	assert.Contains(t, out, `"DB.Password":"******"`)
+	assert.NotContains(t, out, "p@ss")
+	assert.NotContains(t, out, "t1")
+}
+
+func TestDiff(t *testing.T) {
+	old, new := newSecretConfig(), newSecretConfig()
+	new.Name = "app2"
+	new.DB.Password = "secret"
+	assert.Equal(t, []configor.Change{
+		{Path: "Name", Old: "app", New: "app2"},
+		{Path: "DB.Password", Old: configor.Redacted, New: configor.Redacted},
+	}, configor.Diff(old, new))
+}
+
+func TestDumpSecret(t *testing.T) {
+	var buf bytes.Buffer
+	d := &configor.Description{Config: newSecretConfig()}
+	assert.NoError(t, d.Dump(&buf, "yaml"))
+	assert.Contains(t, buf.String(), "password: '******'")
+	assert.NotContains(t, buf.String(), "p@ss")
+}
+
+func TestSecretInMap(t *testing.T) {
+	type db struct {
+		Host     string
+		Password string `secret:"true"`
+	}
+	type config struct {
+		DBs map[string]db
+	}
+	old := config{DBs: map[string]db{"primary": {Host: "db1", Password: "p@ss"}}}
+	new := config{DBs: map[string]db{"primary": {Host: "db1", Password: "s3cret"}}}
+
+	fields := configor.Fields(old)
+	assert.Equal(t, []any{"DBs.primary.Host", "db1", "DBs.primary.Password", configor.Redacted}, fields)
+	assert.Equal(t, []configor.Change{
+		{Path: "DBs.primary.Password", Old: configor.Redacted, New: configor.Redacted},
+	}, configor.Diff(old, new))
+
+	for _, format := range []string{"yaml", "json", "toml"} {
+		var buf bytes.Buffer
+		d := &configor.Description{Config: old}
+		assert.NoError(t, d.Dump(&buf, format))
+		assert.Contains(t, buf.String(), "db1", format)
+		assert.NotContains(t, buf.String(), "p@ss", format)
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	type config struct {
+		DSN    *url.URL `secret:"true"`
+		Mirror *url.URL
+	}
+	dsn, _ := url.Parse("postgres://admin:s3cret@db:5432/app")
+	mirror, _ := url.Parse("https://mirror.example.com")
+	cfg := config{DSN: dsn, Mirror: mirror}
+
+	redacted := configor.Redact(cfg).(config)
+	if assert.NotNil(t, redacted.DSN) {
+		assert.Equal(t, url.URL{}, *redacted.DSN)
+		assert.NotContains(t, fmt.Sprintf("%+v", *redacted.DSN), "s3cret")
+	}
+	assert.Equal(t, mirror.String(), redacted.Mirror.String())
+	assert.Equal(t, "postgres://admin:s3cret@db:5432/app", cfg.DSN.String())
+}
+
+func TestDiffNilAny(t *testing.T) {
+	type config struct {
+		Extra any
+	}
+	assert.Equal(t, []configor.Change{{Path: "Extra", Old: nil, New: "x"}}, configor.Diff(config{}, config{Extra: "x"}))
+	assert.Equal(t, []configor.Change{{Path: "Extra", Old: "x", New: nil}}, configor.Diff(config{Extra: "x"}, config{}))
+	assert.Empty(t, configor.Diff(config{}, config{}))
+}
@@ -0,0 +1,266 @@
+package configor
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const schemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// durationPattern matches the durations time.ParseDuration accepts, the
+// `duration` format of JSON Schema is ISO 8601 (`PT5S`) instead
+const durationPattern = `^[-+]?(0|([0-9]*(\.[0-9]*)?(ns|us|µs|μs|ms|s|m|h))+)$`
+
+// schema is the subset of JSON Schema configor can derive from struct tags
+type schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Default              any                `json:"default,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64           `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Items                *schema            `json:"items,omitempty"`
+	Properties           map[string]*schema `json:"properties,omitempty"`
+	AdditionalProperties *schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	WriteOnly            bool               `json:"writeOnly,omitempty"`
+	Env                  []string           `json:"x-env,omitempty"`
+}
+
+// JSONSchema describes the configuration struct dst as a JSON Schema
+// document. Property names follow the YAML rules (`yaml` tag or lower
+// cased field name), TOML and JSON match them case-insensitively. Every
+// property carries its `default` tag, `required:"true"`, the `validate`
+// rules JSON Schema can express, its `description` tag and the env names
+// it is read from, the latter as `x-env`. Secrets are marked `writeOnly`.
+func (c *Configor) JSONSchema(dst any) ([]byte, error) {
+	t := reflect.TypeOf(dst)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, errors.New("invalid dst, should be struct")
+	}
+
+	root, err := c.structSchema(t, nil, map[reflect.Type]bool{})
+	if err != nil {
+		return nil, err
+	}
+	root.Schema = schemaDraft
+	root.Title = t.Name()
+	return json.MarshalIndent(root, "", "  ")
+}
+
+// JSONSchema describes the configuration struct dst as a JSON Schema document
+func JSONSchema(dst any) ([]byte, error) {
+	return New().JSONSchema(dst)
+}
+
+func (c *Configor) structSchema(t reflect.Type, prefixes []string, visiting map[reflect.Type]bool) (*schema, error) {
+	s := &schema{Type: "object", Properties: map[string]*schema{}}
+	if visiting[t] {
+		return s, nil // recursive types are left open
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		fieldStruct := t.Field(i)
+		if !fieldStruct.IsExported() {
+			continue
+		}
+		key, inline, skip := fieldKey(fieldStruct, "yaml")
+		if skip {
+			continue
+		}
+
+		prop, err := c.typeSchema(fieldStruct.Type, c.getPrefixForStruct(prefixes, &fieldStruct), visiting)
+		if err != nil {
+			return nil, err
+		}
+		if inline && prop.Type == "object" && prop.Properties != nil {
+			for k, v := range prop.Properties {
+				s.Properties[k] = v
+			}
+			s.Required = append(s.Required, prop.Required...)
+			continue
+		}
+
+		if value := fieldStruct.Tag.Get("default"); value != "" {
+			var def any
+			if err := yaml.Unmarshal([]byte(value), &def); err != nil {
+				return nil, errors.Wrapf(err, "invalid default tag of %s", fieldStruct.Name)
+			}
+			prop.Default = def
+		}
+		prop.Description = fieldStruct.Tag.Get("description")
+		prop.WriteOnly = isSecret(fieldStruct)
+		prop.Env = c.envNames(prefixes, &fieldStruct)
+
+		required := fieldStruct.Tag.Get("required") == "true"
+		if applyValidate(prop, fieldStruct.Tag.Get("validate")) {
+			required = true
+		}
+		if required {
+			s.Required = append(s.Required, key)
+		}
+		s.Properties[key] = prop
+	}
+	return s, nil
+}
+
+func (c *Configor) typeSchema(t reflect.Type, prefixes []string, visiting map[reflect.Type]bool) (*schema, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &schema{Type: "string", Format: "date-time"}, nil
+	case t == durationType:
+		return &schema{Type: "string", Pattern: durationPattern}, nil
+	case t == urlType:
+		return &schema{Type: "string", Format: "uri"}, nil
+	case t == regexpType:
+		return &schema{Type: "string"}, nil // RE2, the `regex` format is ECMA-262
+	case isScalar(t):
+		return &schema{Type: "string"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &schema{Type: "boolean"}, nil
+	case reflect.String:
+		return &schema{Type: "string"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &schema{Type: "integer"}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		zero := float64(0)
+		return &schema{Type: "integer", Minimum: &zero}, nil
+	case reflect.Float32, reflect.Float64:
+		return &schema{Type: "number"}, nil
+	case reflect.Struct:
+		return c.structSchema(t, prefixes, visiting)
+	case reflect.Slice, reflect.Array:
+		items, err := c.typeSchema(t.Elem(), append(prefixes[:len(prefixes):len(prefixes)], "{N}"), visiting)
+		if err != nil {
+			return nil, err
+		}
+		return &schema{Type: "array", Items: items}, nil
+	case reflect.Map:
+		values, err := c.typeSchema(t.Elem(), append(prefixes[:len(prefixes):len(prefixes)], "{KEY}"), visiting)
+		if err != nil {
+			return nil, err
+		}
+		return &schema{Type: "object", AdditionalProperties: values}, nil
+	default:
+		return &schema{}, nil // anything goes
+	}
+}
+
+// applyValidate translates the `validate` rules of a field into schema
+// keywords, rules after `dive` apply to the items. It reports whether the
+// field is required.
+func applyValidate(s *schema, tag string) (required bool) {
+	for _, rule := range strings.Split(tag, ",") {
+		if rule == "dive" {
+			if s.Items != nil {
+				applyValidate(s.Items, tag[strings.Index(tag, "dive")+len("dive"):])
+			}
+			return required
+		}
+		if strings.Contains(rule, "|") {
+			continue // alternatives can not be expressed
+		}
+
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "oneof":
+			for _, v := range strings.Fields(param) {
+				s.Enum = append(s.Enum, enumValue(s.Type, v))
+			}
+		case "email", "hostname", "ipv4", "ipv6", "uuid":
+			s.Format = name
+		case "url", "uri":
+			s.Format = "uri"
+		case "ip":
+			s.Format = "ip"
+		case "len", "min", "max", "gt", "gte", "lt", "lte":
+			applyBound(s, name, param)
+		}
+	}
+	return required
+}
+
+func enumValue(typ, v string) any {
+	switch typ {
+	case "integer", "number":
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return v
+}
+
+func applyBound(s *schema, name, param string) {
+	f, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	n := int(f)
+
+	switch s.Type {
+	case "integer", "number":
+		switch name {
+		case "len":
+			s.Minimum, s.Maximum = &f, &f
+		case "min", "gte":
+			s.Minimum = &f
+		case "max", "lte":
+			s.Maximum = &f
+		case "gt":
+			s.ExclusiveMinimum = &f
+		case "lt":
+			s.ExclusiveMaximum = &f
+		}
+	case "string":
+		switch name {
+		case "len":
+			s.MinLength, s.MaxLength = &n, &n
+		case "min", "gte":
+			s.MinLength = &n
+		case "max", "lte":
+			s.MaxLength = &n
+		}
+	case "array":
+		switch name {
+		case "len":
+			s.MinItems, s.MaxItems = &n, &n
+		case "min", "gte":
+			s.MinItems = &n
+		case "max", "lte":
+			s.MaxItems = &n
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package configor_test
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/cocktail828/go-kits/configor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONSchema(t *testing.T) {
+	type config struct {
+		Name string `yaml:"name" required:"true" validate:"oneof=api worker"`
+		DB   struct {
+			Port    uint `default:"3306" validate:"max=65535"`
+			Timeout int  `validate:"gt=0"`
+		}
+		Contacts []struct {
+			Email string `validate:"required,email"`
+		} `validate:"min=1,dive"`
+		Labels map[string]string
+		DBs    map[string]struct {
+			Host string
+		}
+	}
+
+	c := &configor.Configor{EnvPrefix: "APP"}
+	data, err := c.JSONSchema(&config{})
+	if err != nil {
+		t.Fatalf("configor.JSONSchema err:%v", err)
+	}
+	assert.JSONEq(t, `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "title": "config",
+  "type": "object",
+  "properties": {
+    "name": {"type": "string", "enum": ["api", "worker"], "x-env": ["APP_Name", "APP_NAME"]},
+    "db": {
+      "type": "object",
+      "properties": {
+        "port": {"type": "integer", "minimum": 0, "maximum": 65535, "default": 3306, "x-env": ["APP_DB_Port", "APP_DB_PORT"]},
+        "timeout": {"type": "integer", "exclusiveMinimum": 0, "x-env": ["APP_DB_Timeout", "APP_DB_TIMEOUT"]}
+      },
+      "x-env": ["APP_DB"]
+    },
+    "contacts": {
+      "type": "array",
+      "minItems": 1,
+      "items": {
+        "type": "object",
+        "properties": {
+          "email": {"type": "string", "format": "email", "x-env": ["APP_Contacts_{N}_Email", "APP_CONTACTS_{N}_EMAIL"]}
+        },
+        "required": ["email"]
+      },
+      "x-env": ["APP_Contacts", "APP_CONTACTS"]
+    },
+    "labels": {"type": "object", "additionalProperties": {"type": "string"}, "x-env": ["APP_Labels", "APP_LABELS"]},
+    "dbs": {
+      "type": "object",
+      "additionalProperties": {
+        "type": "object",
+        "properties": {
+          "host": {"type": "string", "x-env": ["APP_DBs_{KEY}_Host", "APP_DBS_{KEY}_HOST"]}
+        }
+      },
+      "x-env": ["APP_DBs", "APP_DBS"]
+    }
+  },
+  "required": ["name"]
+}`, string(data))
+}
+
+func TestJSONSchemaTextTypes(t *testing.T) {
+	type config struct {
+		Timeout time.Duration
+		Filter  *regexp.Regexp
+	}
+
+	data, err := configor.JSONSchema(&config{})
+	if err != nil {
+		t.Fatalf("configor.JSONSchema err:%v", err)
+	}
+	var doc struct {
+		Properties map[string]struct {
+			Type    string
+			Format  string
+			Pattern string
+		}
+	}
+	assert.NoError(t, json.Unmarshal(data, &doc))
+
+	// Go durations, not the ISO 8601 ones of the `duration` format
+	timeout := doc.Properties["timeout"]
+	assert.Equal(t, "string", timeout.Type)
+	assert.Empty(t, timeout.Format)
+	pattern := regexp.MustCompile(timeout.Pattern)
+	for _, d := range []string{"5s", "1m30s", "1.5h", "-2ms", "0"} {
+		assert.True(t, pattern.MatchString(d), d)
+	}
+	for _, d := range []string{"PT5S", "5", "soon", ""} {
+		assert.False(t, pattern.MatchString(d), d)
+	}
+
+	// RE2, not the ECMA-262 of the `regex` format
+	assert.Equal(t, "string", doc.Properties["filter"].Type)
+	assert.Empty(t, doc.Properties["filter"].Format)
+}
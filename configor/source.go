@@ -0,0 +1,256 @@
+package configor
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Source is a place a configuration is read from. Sources given to
+// LoadSources are layered in order like files.
+type Source interface {
+	// Name identifies the source in errors and origins, e.g. `file config.yaml`
+	Name() string
+	// Read returns the payload and its format, a registered extension like
+	// `yaml` or `.json`, see Register
+	Read(ctx context.Context) (data []byte, format string, err error)
+}
+
+// Watchable is implemented by sources that can tell whether they changed
+// since they were last read, LoadSourcesAndWatch polls them
+type Watchable interface {
+	Changed(ctx context.Context) (bool, error)
+}
+
+// formatOf returns the extension and unmarshaler of a format name
+func formatOf(format string) (string, func([]byte, any) error, error) {
+	ext := "." + strings.TrimPrefix(format, ".")
+	unmarshaler, ok := unmarshalers[ext]
+	if !ok {
+		return "", nil, errors.Errorf("unsupported format %q", format)
+	}
+	return ext, unmarshaler, nil
+}
+
+// LoadSources loads the sources into dst in order, every source is read
+// once per load
+func (c *Configor) LoadSources(dst any, sources ...Source) error {
+	pairs, err := c.readSources(context.Background(), sources)
+	if err != nil {
+		return err
+	}
+	return c.internalLoad(dst, pairs...)
+}
+
+// LoadSources loads the sources into dst in order
+func LoadSources(dst any, sources ...Source) error {
+	return New().LoadSources(dst, sources...)
+}
+
+func (c *Configor) readSources(ctx context.Context, sources []Source) ([]pair, error) {
+	pairs := make([]pair, 0, len(sources))
+	for _, s := range sources {
+		data, format, err := s.Read(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", s.Name())
+		}
+		ext, unmarshaler, err := formatOf(format)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", s.Name())
+		}
+		pairs = append(pairs, pair{s.Name(), data, unmarshaler, ext, ""})
+	}
+	return pairs, nil
+}
+
+type fileSource struct {
+	fname string
+
+	mu    sync.Mutex
+	state fileState
+}
+
+// NewFileSource reads a single file, its format is its extension.
+// Includes and profile overlays are followed by LoadFile only.
+func NewFileSource(fname string) Source {
+	return &fileSource{fname: fname}
+}
+
+func (s *fileSource) Name() string { return "file " + s.fname }
+
+func (s *fileSource) Read(ctx context.Context) ([]byte, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = statFile(s.fname)
+	data, err := os.ReadFile(s.fname)
+	return data, filepath.Ext(s.fname), err
+}
+
+func (s *fileSource) Changed(ctx context.Context) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return statFile(s.fname).changed(s.state), nil
+}
+
+// HTTPSource reads a configuration from an HTTP(S) URL. It remembers the
+// ETag of the last response and sends it as If-None-Match, a
+// `304 Not Modified` reuses the last payload, so polling it is cheap.
+type HTTPSource struct {
+	URL string
+	// Format is the format of the payload, if empty it is taken from the
+	// Content-Type of the response, then from the extension of the URL
+	Format string
+	// Client defaults to http.DefaultClient
+	Client *http.Client
+	// Header is added to every request, e.g. for authorization
+	Header http.Header
+
+	mu     sync.Mutex
+	etag   string
+	data   []byte
+	format string
+	fresh  bool // data has not been handed to Read yet
+}
+
+// NewHTTPSource reads a configuration from url
+func NewHTTPSource(url string) *HTTPSource {
+	return &HTTPSource{URL: url}
+}
+
+func (s *HTTPSource) Name() string { return "url " + s.URL }
+
+func (s *HTTPSource) Read(ctx context.Context) ([]byte, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.fresh {
+		if _, err := s.fetch(ctx); err != nil {
+			return nil, "", err
+		}
+	}
+	s.fresh = false
+	return s.data, s.format, nil
+}
+
+// Changed fetches the URL, the payload is kept for the next Read
+func (s *HTTPSource) Changed(ctx context.Context) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	changed, err := s.fetch(ctx)
+	if changed {
+		s.fresh = true
+	}
+	return changed, err
+}
+
+// fetch must be called with s.mu held
+func (s *HTTPSource) fetch(ctx context.Context) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return false, err
+	}
+	for k, v := range s.Header {
+		req.Header[k] = v
+	}
+	if s.etag != "" && s.data != nil {
+		req.Header.Set("If-None-Match", s.etag)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return false, nil
+	case http.StatusOK:
+	default:
+		return false, errors.Errorf("unexpected status %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, err
+	}
+	changed := s.data == nil || !bytes.Equal(data, s.data)
+	s.etag, s.data, s.format = resp.Header.Get("ETag"), data, s.formatOf(resp)
+	return changed, nil
+}
+
+func (s *HTTPSource) formatOf(resp *http.Response) string {
+	if s.Format != "" {
+		return s.Format
+	}
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil {
+		switch mediaType {
+		case "application/json":
+			return ".json"
+		case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+			return ".yaml"
+		case "application/toml", "text/toml":
+			return ".toml"
+		}
+	}
+	if u, err := url.Parse(s.URL); err == nil {
+		return path.Ext(u.Path)
+	}
+	return ""
+}
+
+// KV is a key/value store such as etcd or Consul, a small adapter around
+// their clients makes them a Source with NewKVSource
+type KV interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+type kvSource struct {
+	kv     KV
+	key    string
+	format string
+
+	mu   sync.Mutex
+	last []byte
+}
+
+// NewKVSource reads the value of key from kv, the value is in format
+func NewKVSource(kv KV, key, format string) Source {
+	return &kvSource{kv: kv, key: key, format: format}
+}
+
+func (s *kvSource) Name() string { return "key " + s.key }
+
+func (s *kvSource) Read(ctx context.Context) ([]byte, string, error) {
+	data, err := s.kv.Get(ctx, s.key)
+	if err != nil {
+		return nil, "", err
+	}
+	s.mu.Lock()
+	s.last = data
+	s.mu.Unlock()
+	return data, s.format, nil
+}
+
+func (s *kvSource) Changed(ctx context.Context) (bool, error) {
+	data, err := s.kv.Get(ctx, s.key)
+	if err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !bytes.Equal(data, s.last), nil
+}
@@ -0,0 +1,144 @@
+package configor_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cocktail828/go-kits/configor"
+	"github.com/stretchr/testify/assert"
+)
+
+type sourceConfig struct {
+	Name string
+	Port int `default:"80"`
+	Tags []string
+}
+
+// configServer serves a YAML document with an ETag of its version
+type configServer struct {
+	mu       sync.Mutex
+	version  int
+	body     string
+	requests atomic.Int32
+	notMod   atomic.Int32
+}
+
+func (s *configServer) set(body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version++
+	s.body = body
+}
+
+func (s *configServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests.Add(1)
+	etag := fmt.Sprintf(`"v%d"`, s.version)
+	if r.Header.Get("If-None-Match") == etag {
+		s.notMod.Add(1)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Content-Type", "application/yaml")
+	fmt.Fprint(w, s.body)
+}
+
+type mapKV struct {
+	sync.Mutex
+	values map[string][]byte
+}
+
+func (kv *mapKV) Get(ctx context.Context, key string) ([]byte, error) {
+	kv.Lock()
+	defer kv.Unlock()
+	return kv.values[key], nil
+}
+
+func TestLoadSources(t *testing.T) {
+	srv := &configServer{}
+	srv.set("name: remote\ntags: [a]\n")
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	fname := filepath.Join(t.TempDir(), "local.toml")
+	writeFile(t, fname, "Port = 8080\n")
+	kv := &mapKV{values: map[string][]byte{"app/config": []byte(`{"tags": ["b"]}`)}}
+
+	c := configor.New()
+	c.EnvPrefix = "CONFIGOR_SOURCE"
+
+	var cfg sourceConfig
+	assert.NoError(t, c.LoadSources(&cfg,
+		configor.NewHTTPSource(ts.URL+"/config"),
+		configor.NewFileSource(fname),
+		configor.NewKVSource(kv, "app/config", "json"),
+	))
+	assert.Equal(t, sourceConfig{Name: "remote", Port: 8080, Tags: []string{"b"}}, cfg)
+
+	assert.ErrorContains(t, c.LoadSources(&cfg, configor.NewKVSource(kv, "app/config", "ini")), "unsupported format")
+}
+
+func TestHTTPSourceETag(t *testing.T) {
+	srv := &configServer{}
+	srv.set("name: first\n")
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	s := configor.NewHTTPSource(ts.URL)
+	data, format, err := s.Read(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "name: first\n", string(data))
+	assert.Equal(t, ".yaml", format)
+
+	changed, err := s.Changed(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, int32(1), srv.notMod.Load())
+
+	srv.set("name: second\n")
+	changed, err = s.Changed(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, changed)
+
+	// the payload fetched by Changed is reused
+	data, _, err = s.Read(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "name: second\n", string(data))
+	assert.Equal(t, int32(3), srv.requests.Load())
+}
+
+func TestLoadSourcesAndWatch(t *testing.T) {
+	srv := &configServer{}
+	srv.set("name: first\n")
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	var cfg sourceConfig
+	c := &configor.Configor{EnvPrefix: "CONFIGOR_SOURCE", WatchInterval: time.Millisecond * 10}
+	w, err := c.LoadSourcesAndWatch(&cfg, configor.NewHTTPSource(ts.URL))
+	if err != nil {
+		t.Fatalf("configor.LoadSourcesAndWatch err:%v", err)
+	}
+	defer w.Close()
+	assert.Equal(t, "first", cfg.Name)
+
+	changes := make(chan *sourceConfig, 1)
+	w.OnChange(func(v any) { changes <- v.(*sourceConfig) })
+
+	srv.set("name: second\nport: 9090\n")
+	select {
+	case v := <-changes:
+		assert.Equal(t, sourceConfig{Name: "second", Port: 9090}, *v)
+	case <-time.After(time.Second * 3):
+		t.Fatal("configuration is not reloaded")
+	}
+}
@@ -0,0 +1,198 @@
+package configor
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// checkStrict fails with an *UnknownFieldError if val has keys no field
+// of t decodes. Keys are matched the way the load matches them, see
+// treeField, whatever the format; keys of fields the load skips, like
+// `yaml:"-"` ones, are unknown. YAML and JSON keys tell their line.
+func checkStrict(val pair, t reflect.Type) error {
+	keys := treeUnknownKeys(val, t)
+	var lines map[string]int
+	if len(keys) > 0 && val.ext == ".json" {
+		lines = jsonLines(val.payload)
+	}
+	for i, key := range keys {
+		path := strings.Split(key, ".")
+		line := 0
+		switch val.ext {
+		case ".yaml", ".yml":
+			line = yamlLine(val.payload, path)
+		case ".json":
+			line = lines[key]
+		}
+		if line > 0 {
+			keys[i] = "line " + strconv.Itoa(line) + ": " + key
+		}
+	}
+	if len(keys) > 0 {
+		return &UnknownFieldError{Source: val.name, Keys: keys}
+	}
+	return nil
+}
+
+func isIncludeKey(key string) bool {
+	for _, k := range includeKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// yamlLine returns the line of the key at path in a YAML payload, or 0
+func yamlLine(payload []byte, path []string) int {
+	var doc yaml.Node
+	if yaml.Unmarshal(payload, &doc) != nil || len(doc.Content) == 0 {
+		return 0
+	}
+	node, line := doc.Content[0], 0
+	for _, seg := range path {
+		switch node.Kind {
+		case yaml.MappingNode:
+			var next *yaml.Node
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == seg {
+					line, next = node.Content[i].Line, node.Content[i+1]
+					break
+				}
+			}
+			if next == nil {
+				return 0
+			}
+			node = next
+		case yaml.SequenceNode:
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= len(node.Content) {
+				return 0
+			}
+			node = node.Content[i]
+		default:
+			return 0
+		}
+	}
+	return line
+}
+
+// jsonLines maps the dotted path of every key in a JSON payload to its
+// line. Of repeated keys the last one counts, as it does when decoding.
+func jsonLines(payload []byte) map[string]int {
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	lines := map[string]int{}
+	var walk func(path []string) error
+	walk = func(path []string) error {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'):
+			for dec.More() {
+				tok, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				key, _ := tok.(string)
+				elemPath := append(path[:len(path):len(path)], key)
+				lines[strings.Join(elemPath, ".")] = lineOf(payload, payload[dec.InputOffset():])
+				if err := walk(elemPath); err != nil {
+					return err
+				}
+			}
+		case json.Delim('['):
+			for i := 0; dec.More(); i++ {
+				if err := walk(append(path[:len(path):len(path)], strconv.Itoa(i))); err != nil {
+					return err
+				}
+			}
+		default:
+			return nil
+		}
+		_, err = dec.Token() // the closing delimiter
+		return err
+	}
+	_ = walk(nil) // keys up to a syntax error keep their lines
+	return lines
+}
+
+func treeUnknownKeys(val pair, t reflect.Type) []string {
+	tree, err := decodeTree(val)
+	if err != nil {
+		return nil // not a tree format, nothing to check against
+	}
+	var keys []string
+	for k, v := range tree {
+		if !isIncludeKey(k) {
+			collectUnknownKeys(map[string]any{k: v}, t, nil, &keys)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// collectUnknownKeys walks tree along t and lists the dotted paths of the
+// keys no field matches
+func collectUnknownKeys(tree any, t reflect.Type, path []string, keys *[]string) {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || nativeOnly(t) {
+		return
+	}
+
+	switch v := tree.(type) {
+	case map[string]any:
+		for k, elem := range v {
+			elemPath := append(path[:len(path):len(path)], k)
+			switch {
+			case t.Kind() == reflect.Struct && !isScalar(t):
+				fields := treeField(t, k)
+				if fields == nil || skippedField(fields) {
+					*keys = append(*keys, strings.Join(elemPath, "."))
+					continue
+				}
+				collectUnknownKeys(elem, fields[len(fields)-1].Type, elemPath, keys)
+			case t.Kind() == reflect.Map:
+				collectUnknownKeys(elem, t.Elem(), elemPath, keys)
+			}
+		}
+	case []map[string]any: // TOML arrays of tables
+		list := make([]any, len(v))
+		for i, elem := range v {
+			list[i] = elem
+		}
+		collectUnknownKeys(list, t, path, keys)
+	case []any:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return
+		}
+		for i, elem := range v {
+			collectUnknownKeys(elem, t.Elem(), append(path[:len(path):len(path)], strconv.Itoa(i)), keys)
+		}
+	}
+}
+
+// skippedField reports whether the load skips the field at the end of
+// fields, see fieldKey
+func skippedField(fields []reflect.StructField) bool {
+	for _, f := range fields {
+		if _, _, skip := fieldKey(f, "yaml"); skip {
+			return true
+		}
+	}
+	return false
+}
+
+// lineOf returns the line number of rest, which is a suffix of payload
+func lineOf(payload, rest []byte) int {
+	return bytes.Count(payload[:len(payload)-len(rest)], []byte("\n")) + 1
+}
@@ -0,0 +1,107 @@
+package configor_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/cocktail828/go-kits/configor"
+	"github.com/stretchr/testify/assert"
+)
+
+type strictConfig struct {
+	Name string
+	DB   struct {
+		User     string
+		Password string
+	}
+}
+
+func TestStrict(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "base.yaml"), "name: base\n")
+	writeFile(t, filepath.Join(dir, "config.yaml"), "include: base.yaml\ndb:\n  user: root\n  pasword: secret\n")
+	writeFile(t, filepath.Join(dir, "config.toml"), "Name = \"app\"\n[DB]\nUser = \"root\"\nPasword = \"secret\"\n")
+	writeFile(t, filepath.Join(dir, "config.json"), "{\n  \"Name\": \"app\",\n  \"Nmae\": \"app\",\n  \"DB\": {\"Usr\": \"root\"}\n}\n")
+
+	c := configor.New(configor.WithStrict())
+	c.EnvPrefix = "CONFIGOR_STRICT"
+
+	tests := []struct {
+		file string
+		keys []string
+	}{
+		{"config.yaml", []string{"line 4: db.pasword"}},
+		{"config.toml", []string{"DB.Pasword"}},
+		{"config.json", []string{"line 4: DB.Usr", "line 3: Nmae"}},
+	}
+	for _, tt := range tests {
+		var cfg strictConfig
+		err := c.LoadFile(&cfg, filepath.Join(dir, tt.file))
+
+		var unknown *configor.UnknownFieldError
+		if assert.True(t, errors.As(err, &unknown), tt.file) {
+			assert.Equal(t, "file "+filepath.Join(dir, tt.file), unknown.Source)
+			assert.Equal(t, tt.keys, unknown.Keys)
+		}
+	}
+
+	var cfg strictConfig
+	assert.EqualError(t, c.Load(&cfg, []byte("Name = \"app\"\n[DB]\nPasword = \"x\"\n")),
+		"unknown keys in payload #0: DB.Pasword")
+
+	// keys match fields the way a load matches them, ignoring case
+	writeFile(t, filepath.Join(dir, "upper.yaml"), "Name: app\nDB:\n  USER: root\n")
+	assert.NoError(t, c.LoadFile(&cfg, filepath.Join(dir, "upper.yaml")))
+	assert.Equal(t, "root", cfg.DB.User)
+
+	// lenient unless Strict is set
+	c.Strict = false
+	assert.NoError(t, c.LoadFile(&cfg, filepath.Join(dir, "config.yaml")))
+	assert.Equal(t, "base", cfg.Name)
+}
+
+func TestStrictJSONLines(t *testing.T) {
+	type config struct {
+		Name   string
+		Secret string `yaml:"-"`
+		DB     struct {
+			User string
+		}
+		Hosts []struct {
+			Addr string
+		}
+	}
+
+	// repeated keys tell the line of the last one, which is decoded
+	payload := `{
+  "User": "root",
+  "Name": "app",
+  "DB": {
+    "Name": "db",
+    "User": "root"
+  },
+  "Hosts": [
+    {"Addr": "a"},
+    {"Addr": "b", "User": "x"}
+  ],
+  "Secret": "x",
+  "User": "admin"
+}
+`
+	file := filepath.Join(t.TempDir(), "config.json")
+	writeFile(t, file, payload)
+
+	var cfg config
+	err := configor.New(configor.WithStrict()).LoadFile(&cfg, file)
+
+	var unknown *configor.UnknownFieldError
+	if assert.True(t, errors.As(err, &unknown), "%v", err) {
+		assert.Equal(t, []string{
+			"line 5: DB.Name",
+			"line 10: Hosts.1.User",
+			"line 12: Secret",
+			"line 13: User",
+		}, unknown.Keys)
+	}
+}
@@ -0,0 +1,118 @@
+package configor_test
+
+import (
+	"net"
+	"net/netip"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/cocktail828/go-kits/configor"
+	"github.com/stretchr/testify/assert"
+)
+
+type typesConfig struct {
+	Timeout  time.Duration `default:"5s"`
+	MaxBody  configor.ByteSize
+	Endpoint *url.URL `default:"http://localhost:8080"`
+	Mirrors  []*url.URL
+	IP       net.IP
+	Subnet   netip.Prefix
+	Pattern  *regexp.Regexp
+	Since    time.Time
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := map[string]configor.ByteSize{
+		"512":     512,
+		"10MB":    10 * configor.MB,
+		"10 mb":   10 * configor.MB,
+		"64k":     64 * configor.KB,
+		"1.5GiB":  configor.GiB + configor.GiB/2,
+		"2Ti":     2 * configor.TiB,
+		"0":       0,
+		"1000KiB": 1000 * configor.KiB,
+	}
+	for s, want := range tests {
+		got, err := configor.ParseByteSize(s)
+		assert.NoError(t, err, s)
+		assert.Equal(t, want, got, s)
+	}
+	for _, s := range []string{"", "MB", "10XB", "-1", "99999999999PB"} {
+		_, err := configor.ParseByteSize(s)
+		assert.Error(t, err, s)
+	}
+	assert.Equal(t, "10MiB", (10 * configor.MiB).String())
+	assert.Equal(t, "3MB", (3 * configor.MB).String())
+	assert.Equal(t, "1001B", configor.ByteSize(1001).String())
+}
+
+func TestRichTypes(t *testing.T) {
+	dir := t.TempDir()
+	docs := map[string]string{
+		"config.yaml": "timeout: 1m30s\nmaxbody: 10MB\nendpoint: https://api.example.com/v1\nmirrors: [https://a.example.com, https://b.example.com]\nip: 10.0.0.1\nsubnet: 10.0.0.0/8\npattern: ^app-[0-9]+$\nsince: 2024-01-02T03:04:05Z\n",
+		"config.json": `{"Timeout": "1m30s", "MaxBody": "10MB", "Endpoint": "https://api.example.com/v1", "Mirrors": ["https://a.example.com", "https://b.example.com"], "IP": "10.0.0.1", "Subnet": "10.0.0.0/8", "Pattern": "^app-[0-9]+$", "Since": "2024-01-02T03:04:05Z"}`,
+		"config.toml": "Timeout = \"1m30s\"\nMaxBody = \"10MB\"\nEndpoint = \"https://api.example.com/v1\"\nMirrors = [\"https://a.example.com\", \"https://b.example.com\"]\nIP = \"10.0.0.1\"\nSubnet = \"10.0.0.0/8\"\nPattern = \"^app-[0-9]+$\"\nSince = 2024-01-02T03:04:05Z\n",
+	}
+	c := configor.New(configor.WithStrict())
+	c.EnvPrefix = "CONFIGOR_TYPES"
+
+	for name, doc := range docs {
+		fname := filepath.Join(dir, name)
+		writeFile(t, fname, doc)
+
+		var cfg typesConfig
+		if !assert.NoError(t, c.LoadFile(&cfg, fname), name) {
+			continue
+		}
+		assert.Equal(t, 90*time.Second, cfg.Timeout, name)
+		assert.Equal(t, 10*configor.MB, cfg.MaxBody, name)
+		assert.Equal(t, "https://api.example.com/v1", cfg.Endpoint.String(), name)
+		if assert.Len(t, cfg.Mirrors, 2, name) {
+			assert.Equal(t, "b.example.com", cfg.Mirrors[1].Host, name)
+		}
+		assert.Equal(t, "10.0.0.1", cfg.IP.String(), name)
+		assert.Equal(t, netip.MustParsePrefix("10.0.0.0/8"), cfg.Subnet, name)
+		assert.True(t, cfg.Pattern.MatchString("app-42"), name)
+		assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), cfg.Since.UTC(), name)
+	}
+}
+
+func TestRichTypesFromEnv(t *testing.T) {
+	t.Setenv("CONFIGOR_TYPES_TIMEOUT", "250ms")
+	t.Setenv("CONFIGOR_TYPES_MAXBODY", "1.5KiB")
+	t.Setenv("CONFIGOR_TYPES_IP", "::1")
+	t.Setenv("CONFIGOR_TYPES_SUBNET", "fd00::/8")
+	t.Setenv("CONFIGOR_TYPES_PATTERN", "^x$")
+	t.Setenv("CONFIGOR_TYPES_SINCE", "2024-01-02")
+
+	var cfg typesConfig
+	c := configor.New()
+	c.EnvPrefix = "CONFIGOR_TYPES"
+	assert.NoError(t, c.Load(&cfg))
+	assert.Equal(t, 250*time.Millisecond, cfg.Timeout)
+	assert.Equal(t, configor.ByteSize(1536), cfg.MaxBody)
+	assert.Equal(t, "http://localhost:8080", cfg.Endpoint.String()) // default tag
+	assert.Equal(t, net.ParseIP("::1"), cfg.IP)
+	assert.Equal(t, netip.MustParsePrefix("fd00::/8"), cfg.Subnet)
+	assert.True(t, cfg.Pattern.MatchString("x"))
+	assert.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), cfg.Since)
+
+	t.Setenv("CONFIGOR_TYPES_TIMEOUT", "soon")
+	assert.ErrorContains(t, c.Load(&typesConfig{}), "Timeout: time: invalid duration")
+}
+
+func TestBindEnvRichTypes(t *testing.T) {
+	t.Setenv("CONFIGOR_BIND_RATIO", "0.1234567890123")
+	t.Setenv("CONFIGOR_BIND_TIMEOUT", "3s")
+
+	var cfg struct {
+		Ratio   float64       `env:"CONFIGOR_BIND_RATIO"`
+		Timeout time.Duration `env:"CONFIGOR_BIND_TIMEOUT"`
+	}
+	assert.NoError(t, configor.BindEnv(&cfg))
+	assert.Equal(t, 0.1234567890123, cfg.Ratio)
+	assert.Equal(t, 3*time.Second, cfg.Timeout)
+}
@@ -0,0 +1,121 @@
+package configor
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/cocktail828/go-kits/pkg/logger"
+	"golang.org/x/exp/slog"
+)
+
+// UnusedEnv is what a load does about env vars under EnvPrefix that no
+// field reads, most of them are typos
+type UnusedEnv int
+
+const (
+	// UnusedEnvWarn logs a warning through Configor.Logger
+	UnusedEnvWarn UnusedEnv = iota
+	// UnusedEnvIgnore does nothing
+	UnusedEnvIgnore
+	// UnusedEnvReject fails the load with an *UnusedEnvError
+	UnusedEnvReject
+)
+
+// controlEnvs configure configor itself
+var controlEnvs = map[string]bool{
+	"CONFIGOR_ENV_PREFIX": true,
+	"CONFIGOR_ENV":        true,
+	"CONFIGOR_KEY":        true,
+	"CONFIGOR_KEY_FILE":   true,
+}
+
+// UnusedEnvError reports an env var under EnvPrefix that no field reads
+type UnusedEnvError struct {
+	Name string
+	// Suggestion is the closest name a field reads
+	Suggestion string
+}
+
+func (e *UnusedEnvError) Error() string {
+	if e.Suggestion == "" {
+		return fmt.Sprintf("env %s is not read by any field", e.Name)
+	}
+	return fmt.Sprintf("env %s is not read by any field, did you mean %s?", e.Name, e.Suggestion)
+}
+
+func (c *Configor) logger() logger.Logger {
+	if c.Logger != nil {
+		return c.Logger
+	}
+	return logger.NewLoggerWithSlog(slog.Default())
+}
+
+// checkUnusedEnv looks for env vars under EnvPrefix that the load did not
+// read, state.envs holds the names it tried
+func (c *Configor) checkUnusedEnv(state *loadState) {
+	if c.EnvPrefix == "" || c.UnusedEnv == UnusedEnvIgnore {
+		return
+	}
+
+	var unused []string
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if strings.HasPrefix(name, c.EnvPrefix+"_") && !state.envs[name] && !controlEnvs[name] {
+			unused = append(unused, name)
+		}
+	}
+	sort.Strings(unused)
+
+	for _, name := range unused {
+		err := &UnusedEnvError{Name: name, Suggestion: closestName(name, state.envs)}
+		if c.UnusedEnv == UnusedEnvReject {
+			state.errs.add(nil, nil, err)
+		} else {
+			c.logger().Warnw("configor: "+err.Error(), "env", name)
+		}
+	}
+}
+
+// closestName returns the name with the smallest edit distance to name,
+// the first in lexical order on ties
+func closestName(name string, names map[string]bool) string {
+	var (
+		best     string
+		bestDist = -1
+	)
+	for candidate := range names {
+		d := levenshtein(name, candidate)
+		if bestDist < 0 || d < bestDist || d == bestDist && candidate < best {
+			best, bestDist = candidate, d
+		}
+	}
+	return best
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,45 @@
+package configor_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/cocktail828/go-kits/configor"
+	"github.com/cocktail828/go-kits/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/exp/slog"
+)
+
+type unusedEnvConfig struct {
+	DB struct {
+		Password string
+	}
+}
+
+func TestUnusedEnv(t *testing.T) {
+	t.Setenv("CONFIGOR_UNUSED_DB_PASSWORD", "secret")
+	t.Setenv("CONFIGOR_UNUSED_DB_PASWORD", "typo")
+
+	var buf bytes.Buffer
+	c := configor.New(configor.WithLogger(logger.NewLoggerWithSlog(slog.New(slog.NewTextHandler(&buf, nil)))))
+	c.EnvPrefix = "CONFIGOR_UNUSED"
+
+	var cfg unusedEnvConfig
+	assert.NoError(t, c.Load(&cfg))
+	assert.Equal(t, "secret", cfg.DB.Password)
+	assert.Contains(t, buf.String(), "env CONFIGOR_UNUSED_DB_PASWORD is not read by any field, did you mean CONFIGOR_UNUSED_DB_PASSWORD?")
+
+	c.UnusedEnv = configor.UnusedEnvReject
+	err := c.Load(&cfg)
+	var unused *configor.UnusedEnvError
+	if assert.True(t, errors.As(err, &unused)) {
+		assert.Equal(t, "CONFIGOR_UNUSED_DB_PASWORD", unused.Name)
+		assert.Equal(t, "CONFIGOR_UNUSED_DB_PASSWORD", unused.Suggestion)
+	}
+
+	buf.Reset()
+	c.UnusedEnv = configor.UnusedEnvIgnore
+	assert.NoError(t, c.Load(&cfg))
+	assert.Empty(t, buf.String())
+}
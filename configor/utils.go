@@ -1,12 +1,18 @@
 package configor
 
 import (
+	"encoding"
 	"fmt"
+	"io/fs"
+	"net/url"
 	"os"
 	"path"
 	"reflect"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v3"
@@ -19,29 +25,244 @@ func (c *Configor) getPrefixForStruct(prefixes []string, fieldStruct *reflect.St
 	return append(prefixes, fieldStruct.Name)
 }
 
-func (c *Configor) processDefaults(dst any) error {
+// envNames lists the env names read for a field in the order they are tried
+func (c *Configor) envNames(prefixes []string, fieldStruct *reflect.StructField) []string {
+	var envNames []string
+	if envName := fieldStruct.Tag.Get("env"); envName == "" { // read configuration from shell env
+		if c.tagsOnly {
+			return nil
+		}
+		name := strings.Join(append(prefixes[:len(prefixes):len(prefixes)], fieldStruct.Name), "_")
+		envNames = append(envNames, name) // Configor_DB_Name
+		if upper := strings.ToUpper(name); upper != name {
+			envNames = append(envNames, upper) // CONFIGOR_DB_NAME
+		}
+	} else {
+		envNames = []string{envName}
+	}
+	for i, env := range envNames {
+		if c.EnvPrefix != "" {
+			envNames[i] = c.EnvPrefix + "_" + env
+		}
+	}
+	return envNames
+}
+
+// lookupEnv reads the env var name or else the file named by name_FILE,
+// the Docker and Kubernetes secrets convention. One trailing newline of
+// the file is trimmed. src tells which of the two was read.
+func lookupEnv(name string) (value, src string, err error) {
+	if value := os.Getenv(name); value != "" {
+		return value, "env " + name, nil
+	}
+	fname := os.Getenv(name + "_FILE")
+	if fname == "" {
+		return "", "", nil
+	}
+	src = "env " + name + "_FILE"
+	data, err := os.ReadFile(fname)
+	if err != nil {
+		return "", src, err
+	}
+	return strings.TrimSuffix(string(data), "\n"), src, nil
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	urlType      = reflect.TypeOf(url.URL{})
+	regexpType   = reflect.TypeOf(regexp.Regexp{})
+)
+
+// textOnly reports whether values of t are decoded from text by setValue
+// alone, the formats can not decode them
+func textOnly(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t == urlType || t == regexpType
+}
+
+// setValue decodes a text value, like an env var, into field. Strings are
+// taken as is, bools accept strconv.ParseBool forms, durations
+// time.ParseDuration forms and times RFC 3339 or a date. URLs, regexps
+// and encoding.TextUnmarshaler types, like ByteSize, net.IP or
+// netip.Prefix, parse themselves. Lists are `a,b,c` and maps `k=v,k2=v2`
+// unless they are YAML, in flow style or over lines. The rest is YAML.
+func setValue(field reflect.Value, value string) error {
+	for field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		field = field.Elem()
+	}
+
+	switch field.Type() {
+	case durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	case timeType:
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			if t, err = time.Parse("2006-01-02", value); err != nil {
+				return errors.Errorf("invalid time %q, want RFC 3339 or 2006-01-02", value)
+			}
+		}
+		field.Set(reflect.ValueOf(t))
+		return nil
+	case urlType:
+		u, err := url.Parse(value)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(u).Elem())
+		return nil
+	case regexpType:
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(re).Elem())
+		return nil
+	}
+	if u, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(value))
+	}
+
+	switch field.Kind() {
+	case reflect.Bool:
+		val, err := strconv.ParseBool(strings.ToLower(value))
+		if err != nil {
+			return err
+		}
+		field.SetBool(val)
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() == reflect.Uint8 {
+			field.SetBytes([]byte(value))
+			return nil
+		}
+		if isYAMLCollection(value, "[", "- ") {
+			return yaml.Unmarshal([]byte(value), field.Addr().Interface())
+		}
+		// a,b,c
+		parts := strings.Split(value, ",")
+		list := reflect.MakeSlice(field.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := setValue(list.Index(i), strings.TrimSpace(part)); err != nil {
+				return err
+			}
+		}
+		field.Set(list)
+	case reflect.Map:
+		if isYAMLCollection(value, "{") {
+			return yaml.Unmarshal([]byte(value), field.Addr().Interface())
+		}
+		// k=v,k2=v2
+		m := reflect.MakeMap(field.Type())
+		for _, part := range strings.Split(value, ",") {
+			k, v, ok := strings.Cut(part, "=")
+			if !ok {
+				return errors.Errorf("invalid map entry %q, want key=value", part)
+			}
+			key, elem := reflect.New(field.Type().Key()).Elem(), reflect.New(field.Type().Elem()).Elem()
+			if err := setValue(key, strings.TrimSpace(k)); err != nil {
+				return err
+			}
+			if err := setValue(elem, strings.TrimSpace(v)); err != nil {
+				return err
+			}
+			m.SetMapIndex(key, elem)
+		}
+		field.Set(m)
+	default:
+		return yaml.Unmarshal([]byte(value), field.Addr().Interface())
+	}
+	return nil
+}
+
+// isYAMLCollection reports whether value is a YAML list or map rather
+// than a comma separated one, it spans lines or starts with a prefix
+func isYAMLCollection(value string, prefixes ...string) bool {
+	value = strings.TrimSpace(value)
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	return strings.Contains(value, "\n")
+}
+
+// scalarText is the text setValue decodes a scalar of a tree from
+func scalarText(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// loadState carries what a single load has tried and what went wrong
+type loadState struct {
+	sources []string
+	errs    MultiError
+	origins Origins         // nil unless the load is described
+	envs    map[string]bool // the env names tried
+	key     []byte          // the key of encrypted values, once asked for
+}
+
+// record remembers that src set the field at path and everything below it
+func (s *loadState) record(path []string, src string) {
+	if s.origins == nil {
+		return
+	}
+	key := strings.Join(path, ".")
+	for k := range s.origins {
+		if strings.HasPrefix(k, key+".") {
+			delete(s.origins, k)
+		}
+	}
+	s.origins[key] = src
+}
+
+// setDefault decodes a `default` tag, as YAML unless the type only
+// decodes from text
+func setDefault(field reflect.Value, value string) error {
+	if textOnly(field.Type()) {
+		return setValue(field, value)
+	}
+	return yaml.Unmarshal([]byte(value), field.Addr().Interface())
+}
+
+func (c *Configor) processDefaults(dst any, state *loadState, path ...string) error {
 	configValue := reflect.Indirect(reflect.ValueOf(dst))
 	if configValue.Kind() != reflect.Struct {
 		return errors.New("invalid dst, should be struct")
 	}
 
-	configType := configValue.Type()
-	for i := 0; i < configType.NumField(); i++ {
+	for _, fp := range planOf(configValue.Type()).fields {
 		var (
-			fieldStruct = configType.Field(i)
-			field       = configValue.Field(i)
+			fieldStruct = fp.StructField
+			field       = configValue.Field(fp.Index[0])
+			fieldPath   = append(path[:len(path):len(path)], fieldStruct.Name)
 		)
 
-		if !field.CanAddr() || !field.CanInterface() {
-			continue
-		}
-
-		if isBlank := reflect.DeepEqual(field.Interface(), reflect.Zero(field.Type()).Interface()); isBlank {
+		if fp.defaultTag != "" && field.IsZero() {
 			// Set default configuration if blank
-			if value := fieldStruct.Tag.Get("default"); value != "" {
-				if err := yaml.Unmarshal([]byte(value), field.Addr().Interface()); err != nil {
-					return err
-				}
+			if fp.defaultValue.IsValid() {
+				field.Set(fp.defaultValue)
+				state.record(fieldPath, "default tag")
+			} else if err := setDefault(field, fp.defaultTag); err != nil {
+				state.errs.add(fieldPath, []string{"default tag"}, err)
+			} else {
+				state.record(fieldPath, "default tag")
 			}
 		}
 
@@ -51,13 +272,16 @@ func (c *Configor) processDefaults(dst any) error {
 
 		switch field.Kind() {
 		case reflect.Struct:
-			if err := c.processDefaults(field.Addr().Interface()); err != nil {
+			if fp.scalar {
+				break
+			}
+			if err := c.processDefaults(field.Addr().Interface(), state, fieldPath...); err != nil {
 				return err
 			}
 		case reflect.Slice:
 			for i := 0; i < field.Len(); i++ {
-				if reflect.Indirect(field.Index(i)).Kind() == reflect.Struct {
-					if err := c.processDefaults(field.Index(i).Addr().Interface()); err != nil {
+				if elem := reflect.Indirect(field.Index(i)); elem.IsValid() && isStruct(elem.Type()) {
+					if err := c.processDefaults(elem.Addr().Interface(), state, append(fieldPath, fmt.Sprint(i))...); err != nil {
 						return err
 					}
 				}
@@ -68,70 +292,160 @@ func (c *Configor) processDefaults(dst any) error {
 	return nil
 }
 
-func (c *Configor) processTags(config interface{}, prefixes ...string) error {
+// processMapDefaults applies the `default` tags of map values once the
+// payloads are decoded, there are no entries when processDefaults runs
+func (c *Configor) processMapDefaults(v reflect.Value, state *loadState, path []string) error {
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if isScalar(v.Type()) {
+			return nil
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Field(i).CanInterface() {
+				continue
+			}
+			if err := c.processMapDefaults(v.Field(i), state, append(path[:len(path):len(path)], v.Type().Field(i).Name)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := c.processMapDefaults(v.Index(i), state, append(path[:len(path):len(path)], fmt.Sprint(i))); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		return eachMapStruct(v, func(key string, elem reflect.Value) error {
+			entryPath := append(path[:len(path):len(path)], key)
+			if err := c.processDefaults(elem.Addr().Interface(), state, entryPath...); err != nil {
+				return err
+			}
+			return c.processMapDefaults(elem, state, entryPath) // maps within the value
+		})
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of the map m in the order of their text
+func sortedKeys(m reflect.Value) []reflect.Value {
+	keys := m.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+	return keys
+}
+
+// eachMapStruct calls fn with an addressable copy of every struct value
+// of the map m, in key order, and stores the copy back once fn returns
+func eachMapStruct(m reflect.Value, fn func(key string, elem reflect.Value) error) error {
+	if m.Kind() != reflect.Map || !isStruct(m.Type().Elem()) {
+		return nil
+	}
+	for _, key := range sortedKeys(m) {
+		elem := reflect.New(m.Type().Elem()).Elem()
+		elem.Set(m.MapIndex(key))
+		target := elem
+		for target.Kind() == reflect.Ptr && !target.IsNil() {
+			target = target.Elem()
+		}
+		if target.Kind() != reflect.Struct {
+			continue
+		}
+		if err := fn(fmt.Sprint(key), target); err != nil {
+			return err
+		}
+		m.SetMapIndex(key, elem)
+	}
+	return nil
+}
+
+func (c *Configor) processTags(config interface{}, state *loadState, path []string, prefixes ...string) error {
 	configValue := reflect.Indirect(reflect.ValueOf(config))
 	if configValue.Kind() != reflect.Struct {
 		return errors.New("invalid config, should be struct")
 	}
 
-	configType := configValue.Type()
-	for i := 0; i < configType.NumField(); i++ {
+	for _, fp := range planOf(configValue.Type()).fields {
 		var (
-			envNames    []string
-			fieldStruct = configType.Field(i)
-			field       = configValue.Field(i)
-			envName     = fieldStruct.Tag.Get("env") // read configuration from shell env
+			fieldStruct = fp.StructField
+			field       = configValue.Field(fp.Index[0])
+			fieldPath   = append(path[:len(path):len(path)], fieldStruct.Name)
+			names       = c.names(fp, prefixes)
 		)
 
-		if !field.CanAddr() || !field.CanInterface() {
-			continue
+		envNames := names.env
+		for _, name := range envNames {
+			state.envs[name] = true
+			state.envs[name+"_FILE"] = true
 		}
 
-		if envName == "" {
-			envNames = append(envNames, strings.Join(append(prefixes, fieldStruct.Name), "_"))                  // Configor_DB_Name
-			envNames = append(envNames, strings.ToUpper(strings.Join(append(prefixes, fieldStruct.Name), "_"))) // CONFIGOR_DB_NAME
-		} else {
-			envNames = []string{envName}
+		// Load From Shell ENV
+		found := false
+		for _, name := range envNames {
+			value, src, err := lookupEnv(name)
+			if err != nil {
+				state.errs.add(fieldPath, []string{src}, err)
+				found = true
+				break
+			}
+			if value != "" {
+				if value, ok := c.decrypt(state, fieldPath, value); !ok {
+					// recorded by decrypt
+				} else if err := setValue(field, value); err != nil {
+					state.errs.add(fieldPath, []string{src}, err)
+				} else {
+					state.record(fieldPath, src)
+				}
+				found = true
+				break
+			}
+		}
+		for _, name := range envNames {
+			if found || !fp.entries {
+				break
+			}
+			if entries := lookupEnvEntries(field.Type(), name, state, fieldPath); len(entries) > 0 {
+				for i, e := range entries {
+					entries[i].value, _ = c.decrypt(state, append(fieldPath[:len(fieldPath):len(fieldPath)], e.key), e.value)
+				}
+				setEnvEntries(field, entries, state, fieldPath)
+				found = true
+			}
 		}
 
-		// Load From Shell ENV
-	loop:
-		for _, env := range envNames {
-			name := env
-			if c.EnvPrefix != "" {
-				name = c.EnvPrefix + "_" + env
-			}
-			if value := os.Getenv(name); value != "" {
-				switch reflect.Indirect(field).Kind() {
-				case reflect.Bool:
-					if val, err := strconv.ParseBool(strings.ToLower(value)); err == nil {
-						field.Set(reflect.ValueOf(val))
-					}
-					break loop
-				case reflect.String:
-					field.Set(reflect.ValueOf(value))
-					break loop
-				default:
-					if err := yaml.Unmarshal([]byte(value), field.Addr().Interface()); err != nil {
-						return err
-					} else {
-						break loop
-					}
+		// Command line flags take precedence over env
+		if flagName := names.flag; c.FlagSet != nil && flagName != "" {
+			if value, ok := c.FlagSet.Lookup(flagName); ok {
+				if value, ok := c.decrypt(state, fieldPath, value); !ok {
+					// recorded by decrypt
+				} else if err := setValue(field, value); err != nil {
+					state.errs.add(fieldPath, []string{"flag -" + flagName}, err)
+				} else {
+					state.record(fieldPath, "flag -"+flagName)
 				}
 			}
 		}
 
-		if isBlank := reflect.DeepEqual(field.Interface(), reflect.Zero(field.Type()).Interface()); isBlank && fieldStruct.Tag.Get("required") == "true" {
-			// return error if it is required but blank
-			return errors.New(fieldStruct.Name + " is required, but not set")
+		if field.Kind() == reflect.Ptr && field.IsNil() && field.Type().Elem().Kind() == reflect.Struct && !isScalar(field.Type().Elem()) {
+			// a nil struct pointer is allocated if env sets any of its fields
+			elem := reflect.New(field.Type().Elem())
+			if err := c.processTags(elem.Interface(), state, fieldPath, c.getPrefixForStruct(prefixes, &fieldStruct)...); err != nil {
+				return err
+			}
+			if !elem.Elem().IsZero() {
+				field.Set(elem)
+			}
+			continue
 		}
 
 		for field.Kind() == reflect.Ptr {
 			field = field.Elem()
 		}
 
-		if field.Kind() == reflect.Struct {
-			if err := c.processTags(field.Addr().Interface(), c.getPrefixForStruct(prefixes, &fieldStruct)...); err != nil {
+		if field.Kind() == reflect.Struct && !fp.scalar {
+			if err := c.processTags(field.Addr().Interface(), state, fieldPath, c.getPrefixForStruct(prefixes, &fieldStruct)...); err != nil {
 				return err
 			}
 		}
@@ -139,8 +453,8 @@ func (c *Configor) processTags(config interface{}, prefixes ...string) error {
 		if field.Kind() == reflect.Slice {
 			if arrLen := field.Len(); arrLen > 0 {
 				for i := 0; i < arrLen; i++ {
-					if reflect.Indirect(field.Index(i)).Kind() == reflect.Struct {
-						if err := c.processTags(field.Index(i).Addr().Interface(), append(c.getPrefixForStruct(prefixes, &fieldStruct), fmt.Sprint(i))...); err != nil {
+					if elem := reflect.Indirect(field.Index(i)); elem.IsValid() && isStruct(elem.Type()) {
+						if err := c.processTags(elem.Addr().Interface(), state, append(fieldPath, fmt.Sprint(i)), append(c.getPrefixForStruct(prefixes, &fieldStruct), fmt.Sprint(i))...); err != nil {
 							return err
 						}
 					}
@@ -150,15 +464,24 @@ func (c *Configor) processTags(config interface{}, prefixes ...string) error {
 					if !configValue.IsZero() {
 						// load slice from env
 						newVal := reflect.New(field.Type().Elem()).Elem()
-						if newVal.Kind() == reflect.Struct {
+						if isStruct(newVal.Type()) {
 							idx := 0
 							for {
+								elemState := &loadState{sources: state.sources, envs: state.envs}
+								if state.origins != nil {
+									elemState.origins = Origins{}
+								}
 								newVal = reflect.New(field.Type().Elem()).Elem()
-								if err := c.processTags(newVal.Addr().Interface(), append(c.getPrefixForStruct(prefixes, &fieldStruct), fmt.Sprint(idx))...); err != nil {
+								if err := c.processTags(newVal.Addr().Interface(), elemState, append(fieldPath, fmt.Sprint(idx)), append(c.getPrefixForStruct(prefixes, &fieldStruct), fmt.Sprint(idx))...); err != nil {
 									return // err
-								} else if reflect.DeepEqual(newVal.Interface(), reflect.New(field.Type().Elem()).Elem().Interface()) {
+								} else if newVal.IsZero() {
+									// a blank element ends the slice, its errors do not count
 									break
 								} else {
+									state.errs.Errors = append(state.errs.Errors, elemState.errs.Errors...)
+									for k, v := range elemState.origins {
+										state.origins[k] = v
+									}
 									idx++
 									field.Set(reflect.Append(field, newVal))
 								}
@@ -168,51 +491,228 @@ func (c *Configor) processTags(config interface{}, prefixes ...string) error {
 				}(field, fieldStruct)
 			}
 		}
+
+		if field.Kind() == reflect.Map {
+			structPrefixes := c.getPrefixForStruct(prefixes, &fieldStruct)
+			structPrefixes = structPrefixes[:len(structPrefixes):len(structPrefixes)]
+			if err := eachMapStruct(field, func(key string, elem reflect.Value) error {
+				return c.processTags(elem.Addr().Interface(), state, append(fieldPath, key), append(structPrefixes, key)...)
+			}); err != nil {
+				return err
+			}
+			if err := c.addEnvMapEntries(field, state, fieldPath, structPrefixes); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
 
+// processRequired reports the `required:"true"` fields that are still
+// blank once every layer is applied
+func (c *Configor) processRequired(config any, state *loadState, path []string, prefixes ...string) {
+	configValue := reflect.Indirect(reflect.ValueOf(config))
+	for _, fp := range planOf(configValue.Type()).fields {
+		var (
+			fieldStruct = fp.StructField
+			field       = configValue.Field(fp.Index[0])
+			fieldPath   = append(path[:len(path):len(path)], fieldStruct.Name)
+		)
+
+		if fp.required && field.IsZero() {
+			// report it if it is required but blank
+			names := c.names(fp, prefixes)
+			sources := make([]string, 0, len(state.sources)+3)
+			sources = append(sources, state.sources...)
+			sources = append(sources, "env "+strings.Join(names.env, ", "))
+			if flagName := names.flag; c.FlagSet != nil && flagName != "" {
+				sources = append(sources, "flag -"+flagName)
+			}
+			if fp.defaultTag != "" {
+				sources = append(sources, "default tag")
+			}
+			state.errs.add(fieldPath, sources, ErrRequired)
+		}
+
+		for field.Kind() == reflect.Ptr {
+			field = field.Elem()
+		}
+
+		switch field.Kind() {
+		case reflect.Struct:
+			if fp.scalar {
+				break
+			}
+			c.processRequired(field.Addr().Interface(), state, fieldPath, c.getPrefixForStruct(prefixes, &fieldStruct)...)
+		case reflect.Slice:
+			for i := 0; i < field.Len(); i++ {
+				if elem := reflect.Indirect(field.Index(i)); elem.IsValid() && isStruct(elem.Type()) {
+					c.processRequired(elem.Addr().Interface(), state, append(fieldPath, fmt.Sprint(i)), append(c.getPrefixForStruct(prefixes, &fieldStruct), fmt.Sprint(i))...)
+				}
+			}
+		case reflect.Map:
+			structPrefixes := c.getPrefixForStruct(prefixes, &fieldStruct)
+			eachMapStruct(field, func(key string, elem reflect.Value) error {
+				c.processRequired(elem.Addr().Interface(), state, append(fieldPath, key), append(structPrefixes[:len(structPrefixes):len(structPrefixes)], key)...)
+				return nil
+			})
+		}
+	}
+}
+
 type pair struct {
+	name        string
 	payload     []byte
 	unmarshaler func([]byte, any) error
+	ext         string // the extension of the format, like `.yaml`, if known
+	file        string // the OS file payload was read from, if any
+}
+
+func (c *Configor) unmarshalerOf(fname string) func([]byte, any) error {
+	if f, ok := unmarshalers[path.Ext(fname)]; ok {
+		return f
+	}
+	return c.Unmarshaler
 }
 
-func (c *Configor) loadFile(dst any, files ...string) error {
+// readFiles reads files and everything they pull in from fsys, or from
+// the OS if fsys is nil, see LoadFile
+func (c *Configor) readFiles(fsys fs.FS, files ...string) ([]pair, error) {
 	pairs := make([]pair, 0, len(files))
 	for _, fname := range files {
-		data, err := os.ReadFile(fname)
+		layers, err := c.readLayers(fsys, fname, c.Environment)
 		if err != nil {
-			return err
-		}
-		if f, ok := unmarshalers[path.Ext(fname)]; ok {
-			pairs = append(pairs, pair{data, f})
-		} else {
-			pairs = append(pairs, pair{data, c.Unmarshaler})
+			return nil, err
 		}
+		pairs = append(pairs, layers...)
+	}
+	return pairs, nil
+}
+
+func (c *Configor) loadFile(dst any, fsys fs.FS, files ...string) error {
+	pairs, err := c.readFiles(fsys, files...)
+	if err != nil {
+		return err
 	}
 	return c.internalLoad(dst, pairs...)
 }
 
 func (c *Configor) load(dst any, payloads ...[]byte) error {
 	pairs := make([]pair, 0, len(payloads))
-	for _, body := range payloads {
-		pairs = append(pairs, pair{body, c.Unmarshaler})
+	for i, body := range payloads {
+		pairs = append(pairs, pair{fmt.Sprintf("payload #%d", i), body, c.Unmarshaler, "", ""})
 	}
 	return c.internalLoad(dst, pairs...)
 }
 
 func (c *Configor) internalLoad(dst any, pairs ...pair) error {
+	return c.loadWithState(dst, &loadState{}, pairs...)
+}
+
+func (c *Configor) loadWithState(dst any, state *loadState, pairs ...pair) error {
 	defaultValue := reflect.Indirect(reflect.ValueOf(dst))
 	if !defaultValue.CanAddr() {
 		return errors.Errorf("Config %v should be addressable", dst)
 	}
-	if err := c.processDefaults(dst); err != nil {
+
+	if err := c.processDefaults(dst, state); err != nil {
 		return err
 	}
-	for _, val := range pairs {
-		if err := val.unmarshaler(val.payload, dst); err != nil {
-			return err
+	// payloads are decoded into trees and merged, see merge.go. Payloads
+	// that do not decode into a tree are decoded into dst in turn instead.
+	t := defaultValue.Type()
+	trees := make([]map[string]any, 0, len(pairs))
+	exts := make([]string, 0, len(pairs))
+	for i := range pairs {
+		val := &pairs[i]
+		tree, err := decodeTree(*val)
+		if err != nil {
+			// the fields a payload sets are the ones it fills in a blank value
+			blank := reflect.New(t)
+			if err := val.unmarshaler(val.payload, blank.Interface()); err != nil {
+				return errors.Wrapf(err, "failed to load %s", val.name)
+			}
+			trees = nil
+			if state.origins != nil {
+				walkLeaves(blank.Elem(), nil, false, func(path []string, v reflect.Value, _ bool) {
+					if !v.IsZero() {
+						state.record(path, val.name)
+					}
+				})
+			}
+		} else if trees != nil {
+			canonical := canonicalize(tree, t).(map[string]any)
+			if c.Interpolate {
+				if err := c.interpolateTree(canonical, nil); err != nil {
+					return errors.Wrapf(err, "failed to load %s", val.name)
+				}
+			}
+			c.decryptTree(state, canonical, nil)
+			trees = append(trees, canonical)
+			exts = append(exts, val.ext)
+			if state.origins != nil {
+				recordTree(state, canonical, t, nil, val.name)
+			}
+		}
+		if c.Strict {
+			if err := checkStrict(*val, t); err != nil {
+				return err
+			}
+		}
+		state.sources = append(state.sources, val.name)
+	}
+
+	if trees == nil {
+		for _, val := range pairs {
+			if err := val.unmarshaler(val.payload, dst); err != nil {
+				return errors.Wrapf(err, "failed to load %s", val.name)
+			}
+		}
+		if c.Interpolate {
+			var err error
+			replaceStrings(reflect.ValueOf(dst), nil, func(path []string, s string) (string, bool) {
+				value, ierr := c.interpolate(s)
+				if ierr != nil && err == nil {
+					err = errors.Wrap(ierr, strings.Join(path, "."))
+				}
+				return value, ierr == nil && value != s
+			})
+			if err != nil {
+				return errors.Wrap(err, "failed to load configuration")
+			}
+		}
+	} else if err := decodeTrees(dst, trees, exts); err != nil {
+		// blame the first payload that fails on its own, its unmarshaler
+		// tells the line
+		for i := range trees {
+			if decodeTrees(reflect.New(t).Interface(), trees[i:i+1], exts[i:i+1]) == nil {
+				continue
+			}
+			if uerr := pairs[i].unmarshaler(pairs[i].payload, reflect.New(t).Interface()); uerr != nil {
+				return errors.Wrapf(uerr, "failed to load %s", pairs[i].name)
+			}
+			return errors.Wrapf(errors.Cause(err), "failed to load %s", pairs[i].name)
 		}
+		return err
+	}
+	if err := c.processMapDefaults(defaultValue, state, nil); err != nil {
+		return err
+	}
+	if state.envs == nil {
+		state.envs = map[string]bool{}
+	}
+	if err := c.processTags(dst, state, nil); err != nil {
+		return err
+	}
+	c.checkUnusedEnv(state)
+	c.processOverrides(dst, state)
+	c.processEncrypted(dst, state)
+	runDefaulters(dst)
+	c.processRequired(dst, state, nil)
+	c.runValidate(dst, state)
+	runValidators(dst, state)
+	if len(state.errs.Errors) == 0 {
+		runPostLoaders(dst, state)
 	}
-	return c.processTags(dst)
+	return state.errs.errorOrNil()
 }
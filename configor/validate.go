@@ -0,0 +1,51 @@
+package configor
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+func (c *Configor) validator() *validator.Validate {
+	c.validateOnce.Do(func() {
+		c.validate = validator.New()
+	})
+	return c.validate
+}
+
+// RegisterValidation adds a custom `validate` tag checked by every load
+func (c *Configor) RegisterValidation(tag string, fn validator.Func, callValidationEvenIfNull ...bool) error {
+	return c.validator().RegisterValidation(tag, fn, callValidationEvenIfNull...)
+}
+
+// fieldPath converts a validator namespace like `Config.Contacts[0].Email`
+// into the dotted path `Contacts.0.Email`
+func fieldPath(namespace string) string {
+	if i := strings.IndexByte(namespace, '.'); i >= 0 {
+		namespace = namespace[i+1:]
+	}
+	namespace = strings.NewReplacer("[", ".", "]", "").Replace(namespace)
+	return namespace
+}
+
+// runValidate checks the `validate` tags of dst and records every bad field
+func (c *Configor) runValidate(dst any, state *loadState) {
+	err := c.validator().Struct(dst)
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		if err != nil {
+			state.errs.add(nil, nil, err)
+		}
+		return
+	}
+
+	for _, fe := range verrs {
+		cause := fmt.Sprintf("failed on the '%s' tag", fe.Tag())
+		if fe.Param() != "" {
+			cause += fmt.Sprintf(" with '%s'", fe.Param())
+		}
+		state.errs.add(strings.Split(fieldPath(fe.StructNamespace()), "."), nil, errors.New(cause))
+	}
+}
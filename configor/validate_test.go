@@ -0,0 +1,50 @@
+package configor_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cocktail828/go-kits/configor"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+type validateConfig struct {
+	Name string `validate:"required,lowercase"`
+	DB   struct {
+		Host string `validate:"hostname"`
+		Port int    `default:"3306" validate:"min=1,max=65535"`
+	}
+	Contacts []struct {
+		Email string `validate:"email"`
+	} `validate:"dive"`
+}
+
+func TestValidate(t *testing.T) {
+	c := &configor.Configor{EnvPrefix: "CONFIGOR_VALIDATE", Unmarshaler: yaml.Unmarshal}
+
+	var cfg validateConfig
+	assert.NoError(t, c.Load(&cfg, []byte("name: app\ndb:\n  host: localhost\ncontacts:\n  - email: a@b.com")))
+	assert.Equal(t, 3306, cfg.DB.Port)
+
+	err := c.Load(&validateConfig{}, []byte("name: App\ndb:\n  host: localhost\n  port: 70000\ncontacts:\n  - email: x"))
+	if assert.Error(t, err) {
+		for _, path := range []string{"Name", "DB.Port", "Contacts.0.Email"} {
+			assert.Contains(t, err.Error(), path+":")
+		}
+	}
+}
+
+func TestRegisterValidation(t *testing.T) {
+	type config struct {
+		Env string `validate:"env"`
+	}
+	c := &configor.Configor{EnvPrefix: "CONFIGOR_VALIDATE", Unmarshaler: yaml.Unmarshal}
+	assert.NoError(t, c.RegisterValidation("env", func(fl validator.FieldLevel) bool {
+		return strings.Contains("dev staging prod", fl.Field().String())
+	}))
+
+	assert.NoError(t, c.Load(&config{}, []byte("env: prod")))
+	assert.ErrorContains(t, c.Load(&config{}, []byte("env: qa")), "Env: failed on the 'env' tag")
+}
@@ -0,0 +1,133 @@
+package configor
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+type subscriber[T any] struct {
+	id uint64
+	f  func(old, new *T)
+}
+
+// Value holds the current configuration of type T behind an atomic pointer.
+// Every update runs the whole Configor pipeline on a fresh T, so defaults,
+// env overrides and `required` checks apply to each new configuration.
+type Value[T any] struct {
+	c   *Configor
+	ptr atomic.Pointer[T]
+
+	mu      sync.Mutex // serializes updates
+	watcher *Watcher
+
+	subsMu sync.Mutex
+	subs   []subscriber[T]
+	nextID uint64
+}
+
+// NewValue creates a Value loaded by c, the default Configor is used if c is nil
+func NewValue[T any](c *Configor) *Value[T] {
+	if c == nil {
+		c = New()
+	}
+	return &Value[T]{c: c}
+}
+
+// Get returns the current configuration, nil if nothing is loaded yet.
+// The returned value is shared and must not be modified.
+func (v *Value[T]) Get() *T {
+	return v.ptr.Load()
+}
+
+// Load replaces the current configuration with one loaded from payload
+func (v *Value[T]) Load(payload ...[]byte) error {
+	dst := new(T)
+	if err := v.c.Load(dst, payload...); err != nil {
+		return err
+	}
+	v.update(dst)
+	return nil
+}
+
+// LoadFile replaces the current configuration with one loaded from files
+func (v *Value[T]) LoadFile(files ...string) error {
+	dst := new(T)
+	if err := v.c.LoadFile(dst, files...); err != nil {
+		return err
+	}
+	v.update(dst)
+	return nil
+}
+
+// Watch loads files and keeps the Value up to date whenever they change.
+// Reload errors are passed to onError if it is not nil. A previous Watch
+// is stopped once the new one has loaded.
+func (v *Value[T]) Watch(onError func(error), files ...string) error {
+	dst := new(T)
+	_, err := v.c.watch(dst, files, nil, func(w *Watcher) {
+		// before polling starts, so no reload is missed or applied out of order
+		v.mu.Lock()
+		old := v.watcher
+		v.watcher = w
+		v.mu.Unlock()
+		if old != nil {
+			old.Close()
+		}
+
+		v.update(dst)
+		w.OnChange(func(cfg any) { v.update(cfg.(*T)) })
+		if onError != nil {
+			w.OnError(onError)
+		}
+	})
+	return err
+}
+
+// Close stops watching files started by Watch, it waits for a reload in
+// progress to finish
+func (v *Value[T]) Close() error {
+	v.mu.Lock()
+	w := v.watcher
+	v.watcher = nil
+	v.mu.Unlock()
+	if w != nil {
+		w.Close()
+	}
+	return nil
+}
+
+// Subscribe registers f to be called after every update with the previous
+// and the new configuration, old is nil for the first one. Subscribers are
+// called in registration order and updates are delivered one at a time.
+// The returned function removes the subscription.
+func (v *Value[T]) Subscribe(f func(old, new *T)) (cancel func()) {
+	v.subsMu.Lock()
+	defer v.subsMu.Unlock()
+	v.nextID++
+	id := v.nextID
+	v.subs = append(v.subs, subscriber[T]{id, f})
+
+	return func() {
+		v.subsMu.Lock()
+		defer v.subsMu.Unlock()
+		for i, s := range v.subs {
+			if s.id == id {
+				v.subs = append(v.subs[:i:i], v.subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (v *Value[T]) update(dst *T) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	old := v.ptr.Swap(dst)
+	v.subsMu.Lock()
+	subs := v.subs
+	v.subsMu.Unlock()
+	for _, s := range subs {
+		s.f(old, dst)
+	}
+}
@@ -0,0 +1,107 @@
+package configor_test
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cocktail828/go-kits/configor"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestValue(t *testing.T) {
+	c := &configor.Configor{EnvPrefix: "CONFIGOR_VALUE", Unmarshaler: yaml.Unmarshal}
+	v := configor.NewValue[watchConfig](c)
+	assert.Nil(t, v.Get())
+
+	var (
+		mu      sync.Mutex
+		updates [][2]string
+	)
+	name := func(cfg *watchConfig) string {
+		if cfg == nil {
+			return ""
+		}
+		return cfg.Name
+	}
+	cancel := v.Subscribe(func(old, new *watchConfig) {
+		mu.Lock()
+		defer mu.Unlock()
+		updates = append(updates, [2]string{name(old), name(new)})
+	})
+
+	assert.NoError(t, v.Load([]byte("name: first")))
+	assert.Equal(t, &watchConfig{Name: "first", Port: 8080}, v.Get())
+
+	// required field is missing, keep the current configuration
+	assert.Error(t, v.Load([]byte("port: 1")))
+	assert.Equal(t, "first", v.Get().Name)
+
+	assert.NoError(t, v.Load([]byte("name: second")))
+	cancel()
+	assert.NoError(t, v.Load([]byte("name: third")))
+	assert.Equal(t, [][2]string{{"", "first"}, {"first", "second"}}, updates)
+}
+
+func TestValueWatch(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "value.yaml")
+	writeFile(t, fname, "name: first\n")
+
+	c := &configor.Configor{EnvPrefix: "CONFIGOR_VALUE", WatchInterval: time.Millisecond * 10}
+	v := configor.NewValue[watchConfig](c)
+	if err := v.Watch(nil, fname); err != nil {
+		t.Fatalf("Value.Watch err:%v", err)
+	}
+	defer v.Close()
+	assert.Equal(t, "first", v.Get().Name)
+
+	changes := make(chan *watchConfig, 1)
+	v.Subscribe(func(old, new *watchConfig) { changes <- new })
+	writeFile(t, fname, "name: second\n")
+	select {
+	case cfg := <-changes:
+		assert.Equal(t, "second", cfg.Name)
+		assert.Equal(t, cfg, v.Get())
+	case <-time.After(time.Second * 3):
+		t.Fatal("configuration is not reloaded")
+	}
+}
+
+func TestValueRewatch(t *testing.T) {
+	dir := t.TempDir()
+	first, second := filepath.Join(dir, "first.yaml"), filepath.Join(dir, "second.yaml")
+	writeFile(t, first, "name: first\n")
+	writeFile(t, second, "name: second\n")
+
+	c := &configor.Configor{EnvPrefix: "CONFIGOR_VALUE", WatchInterval: time.Millisecond * 10}
+	v := configor.NewValue[watchConfig](c)
+	assert.NoError(t, v.Watch(nil, first))
+	assert.NoError(t, v.Watch(nil, second))
+	assert.Equal(t, "second", v.Get().Name)
+
+	var (
+		mu    sync.Mutex
+		names []string
+	)
+	v.Subscribe(func(_, new *watchConfig) {
+		mu.Lock()
+		defer mu.Unlock()
+		names = append(names, new.Name)
+	})
+
+	// the first watcher is stopped and the second one is not yet closed
+	writeFile(t, first, "name: stale\n")
+	writeFile(t, second, "name: third\n")
+	assert.Eventually(t, func() bool { return v.Get().Name == "third" }, time.Second*3, time.Millisecond*10)
+
+	// no reload is applied once Close returns
+	assert.NoError(t, v.Close())
+	writeFile(t, second, "name: closed\n")
+	time.Sleep(time.Millisecond * 50)
+	assert.Equal(t, "third", v.Get().Name)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"third"}, names)
+}
@@ -0,0 +1,286 @@
+package configor
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const watchInterval = time.Second
+
+// fileState is a snapshot of a watched file, os.Stat follows symlinks so a
+// Kubernetes ConfigMap `..data` swap shows up as a different file.
+type fileState struct {
+	info os.FileInfo
+	err  error
+}
+
+func statFile(fname string) fileState {
+	info, err := os.Stat(fname)
+	return fileState{info, err}
+}
+
+func (s fileState) changed(o fileState) bool {
+	if (s.err == nil) != (o.err == nil) {
+		return true
+	}
+	if s.err != nil {
+		return false
+	}
+	// editors that save by renaming produce a new inode
+	return !os.SameFile(s.info, o.info) ||
+		!s.info.ModTime().Equal(o.info.ModTime()) ||
+		s.info.Size() != o.info.Size()
+}
+
+// Watcher reloads a configuration whenever one of its source files, the
+// files they include or their profile overlays change, or whenever one of
+// its Sources reports a change, see Watchable.
+// Every reload runs the whole defaults -> files -> env pipeline on a fresh
+// value, the previous configuration is kept if the reload fails.
+type Watcher struct {
+	c       *Configor
+	typ     reflect.Type
+	files   []string
+	sources []Source
+
+	current  atomic.Value
+	mu       sync.Mutex
+	watched  []string // files plus what they include and their overlays
+	states   map[string]fileState
+	onChange []func(any)
+	onError  []func(error)
+
+	closeOnce sync.Once
+	done      chan struct{}
+	stopped   chan struct{} // closed when the poll goroutine returns
+}
+
+// LoadAndWatch loads files into dst and keeps watching them for changes.
+// dst is only written by the initial load, reloaded configurations are
+// handed to the OnChange callbacks as new values of the same type.
+func (c *Configor) LoadAndWatch(dst any, files ...string) (*Watcher, error) {
+	return c.watch(dst, files, nil, nil)
+}
+
+// LoadAndWatch loads files into dst and keeps watching them for changes
+func LoadAndWatch(dst any, files ...string) (*Watcher, error) {
+	return New().LoadAndWatch(dst, files...)
+}
+
+// LoadSourcesAndWatch loads sources into dst like LoadSources and polls
+// the Watchable ones for changes, see LoadAndWatch
+func (c *Configor) LoadSourcesAndWatch(dst any, sources ...Source) (*Watcher, error) {
+	return c.watch(dst, nil, sources, nil)
+}
+
+// watch loads dst and starts polling, setup is called in between so that
+// callbacks registered by it see every reload
+func (c *Configor) watch(dst any, files []string, sources []Source, setup func(w *Watcher)) (*Watcher, error) {
+	typ := reflect.TypeOf(dst)
+	if typ == nil || typ.Kind() != reflect.Ptr {
+		return nil, errors.Errorf("Config %v should be a pointer", dst)
+	}
+
+	w := &Watcher{
+		c:       c,
+		typ:     typ.Elem(),
+		files:   files,
+		sources: sources,
+		watched: files,
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	w.states = w.stat()
+	if err := w.load(dst); err != nil {
+		return nil, err
+	}
+	w.current.Store(dst)
+	if setup != nil {
+		setup(w)
+	}
+
+	interval := c.WatchInterval
+	if interval <= 0 {
+		interval = watchInterval
+	}
+	go w.run(interval)
+	return w, nil
+}
+
+// Current returns the latest successfully loaded configuration
+func (w *Watcher) Current() any {
+	return w.current.Load()
+}
+
+// OnChange registers f to be called with every reloaded configuration.
+// Callbacks are called in registration order without the watcher locked,
+// so they may call its methods, Close aside.
+func (w *Watcher) OnChange(f func(cfg any)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onChange = append(w.onChange, f)
+}
+
+// OnError registers f to be called whenever a reload fails
+func (w *Watcher) OnError(f func(err error)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onError = append(w.onError, f)
+}
+
+// Reload loads the files again regardless of whether they changed
+func (w *Watcher) Reload() error {
+	w.mu.Lock()
+	w.states = w.stat()
+	dst, err := w.reload()
+	onChange, onError := w.callbacks()
+	w.mu.Unlock()
+
+	notify(onChange, onError, dst, err)
+	return err
+}
+
+// Close stops watching and waits for a reload in progress to finish, no
+// callback is called once it returns. It is safe to call Close more than
+// once, but not from a callback.
+func (w *Watcher) Close() error {
+	w.closeOnce.Do(func() { close(w.done) })
+	<-w.stopped
+	return nil
+}
+
+func (w *Watcher) stat() map[string]fileState {
+	states := make(map[string]fileState, len(w.watched))
+	for _, fname := range w.watched {
+		states[fname] = statFile(fname)
+	}
+	return states
+}
+
+// load reads the files into dst and updates the list of watched files
+func (w *Watcher) load(dst any) error {
+	if w.sources != nil {
+		pairs, err := w.c.readSources(context.Background(), w.sources)
+		if err != nil {
+			return err
+		}
+		return w.c.internalLoad(dst, pairs...)
+	}
+
+	pairs, err := w.c.readFiles(nil, w.files...)
+	if err != nil {
+		return err
+	}
+	if err := w.c.internalLoad(dst, pairs...); err != nil {
+		return err
+	}
+
+	watched := append([]string{}, w.files...)
+	for _, p := range pairs {
+		watched = append(watched, p.file)
+	}
+	if w.c.Environment != "" {
+		// an overlay created later triggers a reload as well
+		for _, fname := range w.files {
+			watched = append(watched, profileFile(fname, w.c.Environment))
+		}
+	}
+	w.watched = watched
+	for _, fname := range watched {
+		if _, ok := w.states[fname]; !ok {
+			w.states[fname] = statFile(fname)
+		}
+	}
+	return nil
+}
+
+func (w *Watcher) run(interval time.Duration) {
+	defer close(w.stopped)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			w.poll()
+		}
+	}
+}
+
+func (w *Watcher) poll() {
+	w.mu.Lock()
+	states := w.stat()
+	changed := false
+	for fname, state := range states {
+		if old, ok := w.states[fname]; ok && state.changed(old) {
+			changed = true
+		}
+	}
+	w.states = states
+	var errs []error
+	for _, s := range w.sources {
+		ws, ok := s.(Watchable)
+		if !ok {
+			continue
+		}
+		sourceChanged, err := ws.Changed(context.Background())
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to poll %s", s.Name()))
+			continue
+		}
+		changed = changed || sourceChanged
+	}
+	var dst any
+	if changed {
+		var err error
+		if dst, err = w.reload(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	onChange, onError := w.callbacks()
+	w.mu.Unlock()
+
+	for _, err := range errs {
+		notify(nil, onError, nil, err)
+	}
+	if dst != nil {
+		notify(onChange, nil, dst, nil)
+	}
+}
+
+// reload loads a new configuration and makes it the current one, it must
+// be called with w.mu held and the callbacks notified once it is released
+func (w *Watcher) reload() (any, error) {
+	dst := reflect.New(w.typ).Interface()
+	if err := w.load(dst); err != nil {
+		return nil, err
+	}
+	w.current.Store(dst)
+	return dst, nil
+}
+
+// callbacks copies the registered callbacks, it must be called with w.mu
+// held
+func (w *Watcher) callbacks() ([]func(any), []func(error)) {
+	return append([]func(any){}, w.onChange...), append([]func(error){}, w.onError...)
+}
+
+// notify calls onError with err if it is set, or else onChange with dst
+func notify(onChange []func(any), onError []func(error), dst any, err error) {
+	if err != nil {
+		for _, f := range onError {
+			f(err)
+		}
+		return
+	}
+	for _, f := range onChange {
+		f(dst)
+	}
+}
@@ -0,0 +1,173 @@
+package configor_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cocktail828/go-kits/configor"
+	"github.com/stretchr/testify/assert"
+)
+
+type watchConfig struct {
+	Name string `required:"true"`
+	Port int    `default:"8080"`
+}
+
+func writeFile(t *testing.T, fname, body string) {
+	if err := os.MkdirAll(filepath.Dir(fname), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// save by renaming like most editors do
+	tmp := fname + ".tmp"
+	if err := os.WriteFile(tmp, []byte(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, fname); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadAndWatch(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "watch.yaml")
+	writeFile(t, fname, "name: first\n")
+
+	var cfg watchConfig
+	c := &configor.Configor{EnvPrefix: "CONFIGOR_WATCH", WatchInterval: time.Millisecond * 10}
+	w, err := c.LoadAndWatch(&cfg, fname)
+	if err != nil {
+		t.Fatalf("configor.LoadAndWatch err:%v", err)
+	}
+	defer w.Close()
+	assert.Equal(t, watchConfig{Name: "first", Port: 8080}, cfg)
+
+	changes := make(chan *watchConfig, 1)
+	errs := make(chan error, 1)
+	w.OnChange(func(v any) { changes <- v.(*watchConfig) })
+	w.OnError(func(err error) { errs <- err })
+
+	writeFile(t, fname, "name: second\nport: 9090\n")
+	select {
+	case v := <-changes:
+		assert.Equal(t, watchConfig{Name: "second", Port: 9090}, *v)
+		assert.Equal(t, v, w.Current())
+	case <-time.After(time.Second * 3):
+		t.Fatal("configuration is not reloaded")
+	}
+
+	// missing required field, the previous configuration stays
+	writeFile(t, fname, "port: 7070\n")
+	select {
+	case err := <-errs:
+		assert.Error(t, err)
+		assert.Equal(t, "second", w.Current().(*watchConfig).Name)
+	case <-time.After(time.Second * 3):
+		t.Fatal("reload error is not reported")
+	}
+	assert.Equal(t, "first", cfg.Name)
+}
+
+func TestLoadAndWatchSymlinkSwap(t *testing.T) {
+	// mimic the layout of a Kubernetes ConfigMap volume
+	dir := t.TempDir()
+	for _, v := range []string{"v1", "v2"} {
+		if err := os.Mkdir(filepath.Join(dir, v), 0755); err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, filepath.Join(dir, v, "app.yaml"), "name: "+v+"\n")
+	}
+	if err := os.Symlink("v1", filepath.Join(dir, "..data")); err != nil {
+		t.Fatal(err)
+	}
+	fname := filepath.Join(dir, "app.yaml")
+	if err := os.Symlink(filepath.Join("..data", "app.yaml"), fname); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg watchConfig
+	c := &configor.Configor{EnvPrefix: "CONFIGOR_WATCH", WatchInterval: time.Millisecond * 10}
+	w, err := c.LoadAndWatch(&cfg, fname)
+	if err != nil {
+		t.Fatalf("configor.LoadAndWatch err:%v", err)
+	}
+	defer w.Close()
+	assert.Equal(t, "v1", cfg.Name)
+
+	changes := make(chan *watchConfig, 1)
+	w.OnChange(func(v any) { changes <- v.(*watchConfig) })
+
+	if err := os.Symlink("v2", filepath.Join(dir, "..data_tmp")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case v := <-changes:
+		assert.Equal(t, "v2", v.Name)
+	case <-time.After(time.Second * 3):
+		t.Fatal("configuration is not reloaded")
+	}
+}
+
+func TestLoadAndWatchInclude(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "base.yaml"), "name: first\n")
+	writeFile(t, filepath.Join(dir, "app.yaml"), "include: base.yaml\nport: 1\n")
+
+	var cfg watchConfig
+	c := &configor.Configor{EnvPrefix: "CONFIGOR_WATCH", WatchInterval: time.Millisecond * 10}
+	w, err := c.LoadAndWatch(&cfg, filepath.Join(dir, "app.yaml"))
+	if err != nil {
+		t.Fatalf("configor.LoadAndWatch err:%v", err)
+	}
+	defer w.Close()
+
+	changes := make(chan *watchConfig, 1)
+	w.OnChange(func(v any) { changes <- v.(*watchConfig) })
+	writeFile(t, filepath.Join(dir, "base.yaml"), "name: second\n")
+	select {
+	case v := <-changes:
+		assert.Equal(t, watchConfig{Name: "second", Port: 1}, *v)
+	case <-time.After(time.Second * 3):
+		t.Fatal("configuration is not reloaded")
+	}
+}
+
+func TestWatchCallbackReentry(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "watch.yaml")
+	writeFile(t, fname, "name: first\n")
+
+	var cfg watchConfig
+	c := &configor.Configor{EnvPrefix: "CONFIGOR_WATCH", WatchInterval: time.Hour}
+	w, err := c.LoadAndWatch(&cfg, fname)
+	if err != nil {
+		t.Fatalf("configor.LoadAndWatch err:%v", err)
+	}
+	defer w.Close()
+
+	// callbacks may register callbacks, the watcher is not locked
+	var later []string
+	w.OnChange(func(v any) {
+		w.OnChange(func(v any) { later = append(later, v.(*watchConfig).Name) })
+	})
+	w.OnError(func(err error) { w.OnError(func(error) {}) })
+
+	writeFile(t, fname, "name: second\n")
+	done := make(chan error, 1)
+	go func() { done <- w.Reload() }()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second * 3):
+		t.Fatal("Reload deadlocks in a callback")
+	}
+
+	writeFile(t, fname, "name: third\n")
+	assert.NoError(t, w.Reload())
+	assert.Equal(t, []string{"third"}, later)
+
+	writeFile(t, fname, "port: 1\n")
+	assert.Error(t, w.Reload())
+}